@@ -32,38 +32,40 @@ import (
 // ::= [x20-x2f] <binary-data>           # binary data of length 0-15
 // ::= [x34-x37] <binary-data>           # binary data of length 0-1023
 func encBinary(b []byte, v []byte) []byte {
-	var (
-		length  uint16
-		vLength int
-	)
-
 	if len(v) == 0 {
 		//return encByte(b, BC_BINARY_DIRECT)
 		return encByte(b, BC_NULL)
 	}
 
-	vLength = len(v)
-	for vLength > 0 {
-		if vLength > CHUNK_SIZE {
-			length = CHUNK_SIZE
-			b = encByte(b, byte(BC_BINARY_CHUNK), byte(length>>8), byte(length))
-		} else {
-			length = uint16(vLength)
-			if vLength <= int(BINARY_DIRECT_MAX) {
-				b = encByte(b, byte(int(BC_BINARY_DIRECT)+vLength))
-			} else if vLength <= int(BINARY_SHORT_MAX) {
-				b = encByte(b, byte(int(BC_BINARY_SHORT)+vLength>>8), byte(vLength))
-			} else {
-				b = encByte(b, byte(BC_BINARY), byte(vLength>>8), byte(vLength))
-			}
-		}
+	for len(v) > 0 {
+		b, v = encBinaryChunk(b, v)
+	}
 
-		b = append(b, v[:length]...)
-		v = v[length:]
+	return b
+}
+
+// encBinaryChunk encodes the first chunk of @v, and returns the left data.
+func encBinaryChunk(b []byte, v []byte) ([]byte, []byte) {
+	var (
+		length  uint16
 		vLength = len(v)
+	)
+
+	if vLength > CHUNK_SIZE {
+		length = CHUNK_SIZE
+		b = encByte(b, byte(BC_BINARY_CHUNK), byte(length>>8), byte(length))
+	} else {
+		length = uint16(vLength)
+		if vLength <= int(BINARY_DIRECT_MAX) {
+			b = encByte(b, byte(int(BC_BINARY_DIRECT)+vLength))
+		} else if vLength <= int(BINARY_SHORT_MAX) {
+			b = encByte(b, byte(int(BC_BINARY_SHORT)+vLength>>8), byte(vLength))
+		} else {
+			b = encByte(b, byte(BC_BINARY), byte(vLength>>8), byte(vLength))
+		}
 	}
 
-	return b
+	return append(b, v[:length]...), v[length:]
 }
 
 /////////////////////////////////////////
@@ -116,7 +118,10 @@ func (d *Decoder) decBinary(flag int32) ([]byte, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readBufByte()
+		tag, err = d.readBufByte()
+		if err != nil {
+			return nil, jerrors.Trace(err)
+		}
 	}
 
 	if tag == BC_NULL {
@@ -361,8 +361,32 @@ func AddrEqual(x, y interface{}) bool {
 	return v1.Pointer() == v2.Pointer()
 }
 
+// unpackJavaCollection returns the go value of java collection or map @in, eg: the go slice
+// of a java.util.HashSet decoded as JavaSet, or @in itself if it is not a java collection.
+func unpackJavaCollection(in interface{}) interface{} {
+	switch c := in.(type) {
+	case JavaList:
+		return c.List
+	case JavaSet:
+		return c.Values
+	case JavaMap:
+		// go map or pointer to go map
+		if v := reflect.ValueOf(c.Map); v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return nil
+			}
+			return v.Elem().Interface()
+		}
+		return c.Map
+	}
+
+	return in
+}
+
 //SetSlice set value into slice object
 func SetSlice(dest reflect.Value, objects interface{}) error {
+	// set the values of java collection, eg: java.util.HashSet
+	objects = unpackJavaCollection(objects)
 	if objects == nil {
 		return nil
 	}
@@ -155,6 +155,11 @@ const (
 	ARRAY_FLOAT      = "[float"
 	ARRAY_BOOL       = "[boolean"
 	ARRAY_LONG       = "[long"
+	ARRAY_OBJECT     = "[object"
+
+	// the key of the java class name in the map decoded from the object of an unregistered
+	// java class, '@' is not a legal character of java field names.
+	JAVA_CLASS_KEY = "@class"
 
 	PATH_KEY      = "path"
 	INTERFACE_KEY = "interface"
@@ -31,12 +31,41 @@ import (
 // ::= x4b b3 b2 b1 b0       # minutes since epoch
 func encDateInMs(b []byte, v time.Time) []byte {
 	b = append(b, BC_DATE)
-	return append(b, PackInt64(v.UnixNano()/1e6)...)
+	return append(b, PackInt64(unixMilli(v))...)
 }
 
 func encDateInMimute(b []byte, v time.Time) []byte {
 	b = append(b, BC_DATE_MINUTE)
-	return append(b, PackInt32(int32(v.UnixNano()/60e9))...)
+	return append(b, PackInt32(int32(unixMilli(v)/60e3))...)
+}
+
+// encDate encodes @v in the shortest form, just like java Hessian2Output.writeUTCDate.
+func encDate(b []byte, v time.Time) []byte {
+	ms := unixMilli(v)
+	if ms%60e3 == 0 {
+		if m := ms / 60e3; m == int64(int32(m)) {
+			return encDateInMimute(b, v)
+		}
+	}
+
+	return encDateInMs(b, v)
+}
+
+// unixMilli returns the milliseconds of @v since epoch, which is rounded down like java.
+// time.UnixNano is not used because it overflows before 1678 or after 2262.
+func unixMilli(v time.Time) int64 {
+	return v.Unix()*1e3 + int64(v.Nanosecond())/1e6
+}
+
+// unixMilliTime returns the time of @ms milliseconds since epoch in location @loc.
+func unixMilliTime(ms int64, loc *time.Location) time.Time {
+	sec, msec := ms/1e3, ms%1e3
+	if msec < 0 { // before epoch
+		sec--
+		msec += 1e3
+	}
+
+	return time.Unix(sec, msec*1e6).In(loc)
 }
 
 /////////////////////////////////////////
@@ -60,7 +89,10 @@ func (d *Decoder) decDate(flag int32) (time.Time, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return t, jerrors.Trace(err)
+		}
 	}
 
 	switch {
@@ -74,8 +106,7 @@ func (d *Decoder) decDate(flag int32) (time.Time, error) {
 			return t, ErrNotEnoughBuf
 		}
 		i64 = UnpackInt64(s)
-		return time.Unix(i64/1000, i64%1000*10e5), nil
-		// return time.Unix(i64/1000, i64*100), nil
+		return unixMilliTime(i64, d.location()), nil
 
 	case tag == BC_DATE_MINUTE:
 		s = buf[:4]
@@ -87,7 +118,7 @@ func (d *Decoder) decDate(flag int32) (time.Time, error) {
 			return t, ErrNotEnoughBuf
 		}
 		i64 = int64(UnpackInt32(s))
-		return time.Unix(i64*60, 0), nil
+		return time.Unix(i64*60, 0).In(d.location()), nil
 
 	default:
 		return t, jerrors.Errorf("decDate Invalid type: %v", tag)
@@ -65,3 +65,92 @@ func TestDate(t *testing.T) {
 	testDateFramework(t, "replyDate_1", time.Date(1998, 5, 8, 9, 51, 31, 0, time.UTC))
 	testDateFramework(t, "replyDate_2", time.Date(1998, 5, 8, 9, 51, 0, 0, time.UTC))
 }
+
+func TestDecDateLocation(t *testing.T) {
+	shanghai, _ := time.LoadLocation("Asia/Shanghai")
+	dates := []time.Time{
+		time.Date(2019, 5, 20, 13, 14, 0, 0, time.UTC),
+		time.Date(2019, 5, 20, 13, 14, 15, 678000000, time.UTC),
+		// before epoch
+		time.Date(1969, 12, 31, 23, 59, 59, 999000000, time.UTC),
+		time.Date(1900, 1, 1, 0, 0, 0, 1000000, time.UTC),
+		// out of the range of time.UnixNano
+		time.Date(1600, 1, 1, 0, 0, 0, 500000000, time.UTC),
+	}
+
+	for _, date := range dates {
+		e := NewEncoder()
+		if err := e.Encode(date); err != nil {
+			t.Fatalf("Encode(%v) = error:%v", date, err)
+		}
+
+		d := NewDecoder(e.Buffer())
+		res, err := d.Decode()
+		if err != nil {
+			t.Fatalf("Decode() = error:%v", err)
+		}
+		if res != date {
+			t.Errorf("decode %v, got %v", date, res)
+		}
+
+		d = NewDecoder(e.Buffer())
+		d.SetLocation(shanghai)
+		res, err = d.Decode()
+		if err != nil {
+			t.Fatalf("Decode() = error:%v", err)
+		}
+		if res.(time.Time).Location() != shanghai || !res.(time.Time).Equal(date) {
+			t.Errorf("decode %v in Asia/Shanghai, got %v", date, res)
+		}
+	}
+
+	// the sub-millisecond of a date before epoch is rounded down like java
+	e := NewEncoder()
+	e.Encode(time.Unix(0, -1500000))
+	res, _ := NewDecoder(e.Buffer()).Decode()
+	if !res.(time.Time).Equal(time.Unix(0, -2000000)) {
+		t.Errorf("decode %v, got %v", time.Unix(0, -1500000), res)
+	}
+}
+
+func TestEncDateInMinute(t *testing.T) {
+	cases := []struct {
+		date     time.Time
+		expected []byte
+	}{
+		{time.Date(1998, 5, 8, 9, 51, 0, 0, time.UTC), []byte{BC_DATE_MINUTE, 0x00, 0xe3, 0x83, 0x8f}},
+		{time.Date(1998, 5, 8, 9, 51, 31, 0, time.UTC), []byte{BC_DATE, 0x00, 0x00, 0x00, 0xd0, 0x4b, 0x92, 0x84, 0xb8}},
+		{time.Date(1969, 12, 31, 23, 59, 0, 0, time.UTC), []byte{BC_DATE_MINUTE, 0xff, 0xff, 0xff, 0xff}},
+		// the minutes overflow int32
+		{time.Date(7000, 1, 1, 0, 0, 0, 0, time.UTC), append([]byte{BC_DATE}, PackInt64(unixMilli(time.Date(7000, 1, 1, 0, 0, 0, 0, time.UTC)))...)},
+	}
+
+	for _, c := range cases {
+		e := NewEncoder()
+		e.Encode(c.date)
+		assertEqual(c.expected, e.Buffer(), t)
+
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil || !res.(time.Time).Equal(c.date) {
+			t.Errorf("decode %v, got %v, %v", c.date, res, err)
+		}
+	}
+}
+
+func TestDateArg(t *testing.T) {
+	testJavaDecode(t, "argDate_0", time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC))
+	testJavaDecode(t, "argDate_1", time.Date(1998, 5, 8, 9, 51, 31, 0, time.UTC))
+	testJavaDecode(t, "argDate_2", time.Date(1998, 5, 8, 9, 51, 0, 0, time.UTC))
+
+	// byte for byte the same as java
+	for _, method := range []string{"replyDate_0", "replyDate_1", "replyDate_2"} {
+		r, err := decodeResponse(method)
+		if err != nil {
+			t.Errorf("%s: decode fail with error %v", method, err)
+			continue
+		}
+		e := NewEncoder()
+		e.Encode(r)
+		assertEqual(getReply(method), e.Buffer(), t)
+	}
+}
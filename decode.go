@@ -48,7 +48,9 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+	"math"
 	"reflect"
+	"time"
 )
 
 import (
@@ -57,8 +59,14 @@ import (
 
 type Decoder struct {
 	reader        *bufio.Reader
+	source        *bytes.Reader // the byte source of a decoder created by NewDecoder
 	refs          []interface{}
 	classInfoList []classInfo
+	strict        bool
+	skippedFields map[string][]string // java class name --> skipped java field names
+	loc           *time.Location      // the location of decoded dates
+	typeRefs      []string            // the java types of lists and maps
+	objectAsMap   bool                // decode the objects of unregistered java classes as maps
 }
 
 var (
@@ -67,16 +75,98 @@ var (
 )
 
 func NewDecoder(b []byte) *Decoder {
-	return &Decoder{reader: bufio.NewReader(bytes.NewReader(b))}
+	source := bytes.NewReader(b)
+	return &Decoder{reader: bufio.NewReader(source), source: source}
+}
+
+// NewDecoderFromReader creates a decoder which decodes values one after another from @r.
+// Decode returns io.EOF when @r ends between two values, and io.ErrUnexpectedEOF
+// when @r ends in the middle of a value.
+func NewDecoderFromReader(r io.Reader) *Decoder {
+	return &Decoder{reader: bufio.NewReader(r)}
+}
+
+// SetStrict sets whether decoding should fail if a java object has a field
+// which can not be found in its go struct. Such fields are skipped by default.
+func (d *Decoder) SetStrict(strict bool) {
+	d.strict = strict
+}
+
+// SetUnregisteredObjectAsMap sets whether the objects of the java classes which have
+// not been registered are decoded as map[string]interface{} keyed by the java field
+// names, whose java class name is the value of key JAVA_CLASS_KEY. Such objects are
+// decoded as *JavaObject by default.
+func (d *Decoder) SetUnregisteredObjectAsMap(asMap bool) {
+	d.objectAsMap = asMap
+}
+
+// SetLocation sets the location of the decoded dates, which is time.UTC by default.
+func (d *Decoder) SetLocation(loc *time.Location) {
+	d.loc = loc
+}
+
+func (d *Decoder) location() *time.Location {
+	if d.loc == nil {
+		return time.UTC
+	}
+	return d.loc
+}
+
+// SkippedFields returns the java fields which have been skipped because they
+// can not be found in the go structs, the key of the map is the java class name.
+func (d *Decoder) SkippedFields() map[string][]string {
+	return d.skippedFields
+}
+
+// Reset makes @d decode @b from the beginning, and clears its references, class
+// definitions and skipped fields. The allocated buffers and the options are kept.
+func (d *Decoder) Reset(b []byte) {
+	if d.source == nil {
+		d.source = bytes.NewReader(b)
+	} else {
+		d.source.Reset(b)
+	}
+	d.reader.Reset(d.source)
+
+	for i := range d.refs {
+		d.refs[i] = nil
+	}
+	d.refs = d.refs[:0]
+	for i := range d.classInfoList {
+		d.classInfoList[i] = classInfo{}
+	}
+	d.classInfoList = d.classInfoList[:0]
+	for i := range d.typeRefs {
+		d.typeRefs[i] = ""
+	}
+	d.typeRefs = d.typeRefs[:0]
+	d.skippedFields = nil
 }
 
 /////////////////////////////////////////
 // utilities
 /////////////////////////////////////////
 
+// 记录被忽略的 java 类成员
+func (d *Decoder) addSkippedField(javaName, fieldName string) {
+	if d.skippedFields == nil {
+		d.skippedFields = make(map[string][]string)
+	}
+	for _, f := range d.skippedFields[javaName] {
+		if f == fieldName {
+			return
+		}
+	}
+	d.skippedFields[javaName] = append(d.skippedFields[javaName], fieldName)
+}
+
 // 读取当前字节,指针不前移
-func (d *Decoder) peekByte() byte {
-	return d.peek(1)[0]
+func (d *Decoder) peekByte() (byte, error) {
+	b, err := d.peek(1)
+	if err != nil {
+		return 0, err
+	}
+	return b[0], nil
 }
 
 // 获取缓冲长度
@@ -97,13 +187,34 @@ func (d *Decoder) unreadByte() error {
 
 // 读取指定长度的字节,并后移len(b)个字节
 func (d *Decoder) next(b []byte) (int, error) {
-	return d.reader.Read(b)
+	return io.ReadFull(d.reader, b)
 }
 
 // 读取指定长度字节,指针不后移
-func (d *Decoder) peek(n int) []byte {
-	b, _ := d.reader.Peek(n)
-	return b
+func (d *Decoder) peek(n int) ([]byte, error) {
+	return d.reader.Peek(n)
+}
+
+// 检查 list 或者 map 的结束标志 'Z', 如果是则读取该字节
+func (d *Decoder) readEnd() (bool, error) {
+	b, err := d.peekByte()
+	if err != nil {
+		return false, jerrors.Trace(unexpectedEOF(err))
+	}
+	if b != BC_END {
+		return false, nil
+	}
+
+	_, err = d.readByte()
+	return true, jerrors.Trace(err)
+}
+
+// unexpectedEOF converts io.EOF to io.ErrUnexpectedEOF, which means the input ends in the middle of a value.
+func unexpectedEOF(err error) error {
+	if jerrors.Cause(err) == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
 }
 
 // 读取len(s)的 utf8 字符
@@ -128,6 +239,7 @@ func (d *Decoder) nextRune(s []rune) []rune {
 }
 
 // 读取数据类型描述,用于 list 和 map
+// the type is a string, or the ref index of a type decoded before
 func (d *Decoder) decType() (string, error) {
 	var (
 		err error
@@ -135,43 +247,52 @@ func (d *Decoder) decType() (string, error) {
 		buf []byte
 		tag byte
 		idx int32
-		typ reflect.Type
+		typ string
 	)
 
 	buf = arr[:1]
 	if _, err = io.ReadFull(d.reader, buf); err != nil {
-		return "", jerrors.Trace(err)
+		return "", jerrors.Trace(unexpectedEOF(err))
 	}
 	tag = buf[0]
 	if (tag >= BC_STRING_DIRECT && tag <= STRING_DIRECT_MAX) ||
 		(tag >= 0x30 && tag <= 0x33) || (tag == BC_STRING) || (tag == BC_STRING_CHUNK) {
-		return d.decString(int32(tag))
+		if typ, err = d.decString(int32(tag)); err != nil {
+			return "", jerrors.Trace(err)
+		}
+		d.typeRefs = append(d.typeRefs, typ)
+		return typ, nil
 	}
 
-	if idx, err = d.decInt32(TAG_READ); err != nil {
+	if idx, err = d.decInt32(int32(tag)); err != nil {
 		return "", jerrors.Trace(err)
 	}
-
-	typ, _, err = d.getStructDefByIndex(int(idx))
-	if err == nil {
-		return typ.String(), nil
+	if idx < 0 || int(idx) >= len(d.typeRefs) {
+		return "", jerrors.Errorf("illegal type ref index %d", idx)
 	}
 
-	return "", err
+	return d.typeRefs[idx], nil
 }
 
 // 解析 hessian 数据包
 func (d *Decoder) Decode() (interface{}, error) {
-	var (
-		err error
-		tag byte
-	)
-
-	tag, err = d.readByte()
+	tag, err := d.readByte()
 	if err == io.EOF {
 		return nil, err
 	}
+	if err != nil {
+		return nil, jerrors.Trace(err)
+	}
+
+	v, err := d.decValue(tag)
+	if err != nil && tag != BC_END && jerrors.Cause(err) == io.EOF {
+		return nil, jerrors.Annotatef(io.ErrUnexpectedEOF, "decode value of tag %#x", tag)
+	}
 
+	return v, err
+}
+
+func (d *Decoder) decValue(tag byte) (interface{}, error) {
 	switch {
 	case tag == BC_END:
 		// return EOF error for end flag 'Z'
@@ -229,6 +350,301 @@ func (d *Decoder) Decode() (interface{}, error) {
 		return d.decObject(int32(tag))
 
 	default:
-		return nil, jerrors.Errorf("Invalid type: %v,>>%v<<<", string(tag), d.peek(d.len()))
+		buf, _ := d.peek(d.len())
+		return nil, jerrors.Errorf("Invalid type: %v,>>%v<<<", string(tag), buf)
+	}
+}
+
+// DecodeValue decodes the next hessian value into @out, which should be a non-nil pointer.
+// Like encoding/json, the value is converted by the type of @out rather than the wire type,
+// eg: a list of objects can be decoded into a []*User, a map into a map[string]int64 or a struct.
+func (d *Decoder) DecodeValue(out interface{}) error {
+	v := reflect.ValueOf(out)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return jerrors.Errorf("@out should be a non-nil pointer, but got %T", out)
+	}
+
+	in, err := d.Decode()
+	if err != nil {
+		return err
+	}
+
+	return jerrors.Trace(assignValue(v.Elem(), in))
+}
+
+// unpackDecodedValue unpacks the reflect.Value and ref holder returned by Decode to its real value.
+func unpackDecodedValue(in interface{}) interface{} {
+	for {
+		switch v := in.(type) {
+		case reflect.Value:
+			if !v.IsValid() || !v.CanInterface() {
+				return nil
+			}
+			in = v.Interface()
+		case *_refHolder:
+			in = v.value
+		default:
+			return in
+		}
+	}
+}
+
+// assignValue converts @in into the type of @dest and sets it into @dest.
+func assignValue(dest reflect.Value, in interface{}) error {
+	a := valueAssigner{seen: make(map[assignKey]reflect.Value)}
+	return a.assign(dest, in)
+}
+
+// assignKey is a converted pointer, map or slice and the type it is converted to.
+type assignKey struct {
+	ptr uintptr
+	len int
+	typ reflect.Type
+}
+
+// valueAssigner converts the decoded values. The pointers, maps and slices
+// which have been converted are kept, so that the same value is converted
+// only once, and a circular reference is converted into a circular one too.
+type valueAssigner struct {
+	seen map[assignKey]reflect.Value
+}
+
+// converted returns the key of @v converted to @typ, and the converted value if any.
+func (a *valueAssigner) converted(v reflect.Value, typ reflect.Type) (assignKey, reflect.Value, bool) {
+	key := assignKey{typ: typ}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map:
+		key.ptr = v.Pointer()
+	case reflect.Slice:
+		key.ptr, key.len = v.Pointer(), v.Len()
+	default:
+		return key, reflect.Value{}, false
+	}
+	c, ok := a.seen[key]
+	return key, c, ok
+}
+
+func (a *valueAssigner) assign(dest reflect.Value, in interface{}) error {
+	in = unpackDecodedValue(in)
+	if in == nil {
+		dest.Set(reflect.Zero(dest.Type()))
+		return nil
+	}
+
+	v := reflect.ValueOf(in)
+	if v.Type().AssignableTo(dest.Type()) {
+		dest.Set(v)
+		return nil
+	}
+
+	// the values of java collection or map, eg: java.util.HashSet
+	switch l := in.(type) {
+	case JavaList:
+		return a.assign(dest, l.List)
+	case JavaSet:
+		return a.assign(dest, l.Values)
+	case JavaMap:
+		return a.assign(dest, l.Map)
+	}
+
+	if dest.Kind() == reflect.Ptr {
+		key, c, ok := a.converted(v, dest.Type())
+		if ok {
+			dest.Set(c)
+			return nil
+		}
+		if dest.IsNil() {
+			dest.Set(reflect.New(dest.Type().Elem()))
+		}
+		if key.ptr != 0 {
+			a.seen[key] = dest.Elem().Addr()
+		}
+		return a.assign(dest.Elem(), in)
+	}
+
+	// the object of java class without go type, whose fields are assigned by java field names
+	if o, ok := in.(*JavaObject); ok {
+		fields := make(map[string]interface{}, len(o.Fields))
+		for i := 0; i < len(o.Fields) && i < len(o.Values); i++ {
+			fields[o.Fields[i]] = o.Values[i]
+		}
+		return a.assign(dest, fields)
+	}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			dest.Set(reflect.Zero(dest.Type()))
+			return nil
+		}
+		return a.assign(dest, v.Elem().Interface())
 	}
+
+	switch dest.Kind() {
+	case reflect.Bool:
+		if v.Kind() == reflect.Bool {
+			dest.SetBool(v.Bool())
+			return nil
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i64 int64
+		switch {
+		case validateIntKind(v.Kind()):
+			i64 = v.Int()
+		case validateUintKind(v.Kind()) && v.Uint() <= math.MaxInt64:
+			i64 = int64(v.Uint())
+		case validateFloatKind(v.Kind()) && v.Float() == math.Trunc(v.Float()):
+			i64 = int64(v.Float())
+		default:
+			return jerrors.Errorf("can not convert %s(%v) to %s", v.Type(), in, dest.Type())
+		}
+		if dest.OverflowInt(i64) {
+			return jerrors.Errorf("value %v overflows %s", in, dest.Type())
+		}
+		dest.SetInt(i64)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		var u64 uint64
+		switch {
+		case validateIntKind(v.Kind()) && v.Int() >= 0:
+			u64 = uint64(v.Int())
+		case validateUintKind(v.Kind()):
+			u64 = v.Uint()
+		case validateFloatKind(v.Kind()) && v.Float() >= 0 && v.Float() == math.Trunc(v.Float()):
+			u64 = uint64(v.Float())
+		default:
+			return jerrors.Errorf("can not convert %s(%v) to %s", v.Type(), in, dest.Type())
+		}
+		if dest.OverflowUint(u64) {
+			return jerrors.Errorf("value %v overflows %s", in, dest.Type())
+		}
+		dest.SetUint(u64)
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		switch {
+		case validateFloatKind(v.Kind()):
+			dest.SetFloat(v.Float())
+		case validateIntKind(v.Kind()):
+			dest.SetFloat(float64(v.Int()))
+		case validateUintKind(v.Kind()):
+			dest.SetFloat(float64(v.Uint()))
+		default:
+			return jerrors.Errorf("can not convert %s(%v) to %s", v.Type(), in, dest.Type())
+		}
+		return nil
+
+	case reflect.String:
+		if v.Kind() == reflect.String {
+			dest.SetString(v.String())
+			return nil
+		}
+
+	case reflect.Slice:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			break
+		}
+		key, c, ok := a.converted(v, dest.Type())
+		if ok {
+			dest.Set(c)
+			return nil
+		}
+		s := reflect.MakeSlice(dest.Type(), v.Len(), v.Len())
+		if key.ptr != 0 {
+			a.seen[key] = s
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := a.assign(s.Index(i), v.Index(i).Interface()); err != nil {
+				return jerrors.Annotatef(err, "slice index %d", i)
+			}
+		}
+		dest.Set(s)
+		return nil
+
+	case reflect.Array:
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			break
+		}
+		if v.Len() > dest.Len() {
+			return jerrors.Errorf("can not convert %d elements to %s", v.Len(), dest.Type())
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := a.assign(dest.Index(i), v.Index(i).Interface()); err != nil {
+				return jerrors.Annotatef(err, "array index %d", i)
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		if v.Kind() != reflect.Map {
+			break
+		}
+		key, c, ok := a.converted(v, dest.Type())
+		if ok {
+			dest.Set(c)
+			return nil
+		}
+		m := reflect.MakeMapWithSize(dest.Type(), v.Len())
+		if key.ptr != 0 {
+			a.seen[key] = m
+		}
+		for _, k := range v.MapKeys() {
+			key := reflect.New(dest.Type().Key()).Elem()
+			if err := a.assign(key, k.Interface()); err != nil {
+				return jerrors.Annotatef(err, "map key %v", k)
+			}
+			value := reflect.New(dest.Type().Elem()).Elem()
+			if err := a.assign(value, v.MapIndex(k).Interface()); err != nil {
+				return jerrors.Annotatef(err, "map value of key %v", k)
+			}
+			m.SetMapIndex(key, value)
+		}
+		dest.Set(m)
+		return nil
+
+	case reflect.Struct:
+		switch v.Kind() {
+		case reflect.Map:
+			// the map keys are java field names
+			for _, k := range v.MapKeys() {
+				name, ok := unpackDecodedValue(k.Interface()).(string)
+				if !ok {
+					continue
+				}
+				index, err := findField(name, dest.Type())
+				if err != nil {
+					continue
+				}
+				if err = a.assign(fieldByIndex(dest, index, true), v.MapIndex(k).Interface()); err != nil {
+					return jerrors.Annotatef(err, "field %s", name)
+				}
+			}
+			return nil
+
+		case reflect.Struct:
+			for _, f := range getFields(v.Type()) {
+				index, err := findField(f.name, dest.Type())
+				if err != nil {
+					continue
+				}
+				field := fieldByIndex(v, f.index, false)
+				if !field.IsValid() {
+					continue
+				}
+				if err = a.assign(fieldByIndex(dest, index, true), field.Interface()); err != nil {
+					return jerrors.Annotatef(err, "field %s", f.name)
+				}
+			}
+			return nil
+		}
+
+	case reflect.Interface:
+		if v.Type().Implements(dest.Type()) {
+			dest.Set(v)
+			return nil
+		}
+	}
+
+	return jerrors.Errorf("can not convert %s to %s", v.Type(), dest.Type())
 }
@@ -20,9 +20,20 @@
 package hessian
 
 import (
+	"bytes"
+	"io"
 	"log"
 	"os"
 	"os/exec"
+	"reflect"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+import (
+	jerrors "github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
 )
 
 const (
@@ -63,6 +74,29 @@ func getReply(method string) []byte {
 	return out
 }
 
+// testJavaDecode encodes @arg and checks it with the java servlet @method
+func testJavaDecode(t *testing.T, method string, arg interface{}) bool {
+	e := NewEncoder()
+	if err := e.Encode(arg); err != nil {
+		t.Errorf("%s: encode(%#v) = error:%v", method, arg, err)
+		return false
+	}
+
+	genHessianJar()
+	cmd := exec.Command("java", "-jar", hessianJar, method)
+	cmd.Stdin = bytes.NewReader(e.Buffer())
+	out, err := cmd.Output()
+	if err != nil {
+		t.Errorf("%s: java decode error:%v", method, err)
+		return false
+	}
+	if string(out) != "true" {
+		t.Errorf("%s: java decode %#v, got %s", method, arg, out)
+		return false
+	}
+	return true
+}
+
 func decodeResponse(method string) (interface{}, error) {
 	b := getReply(method)
 	d := NewDecoder(b)
@@ -72,3 +106,183 @@ func decodeResponse(method string) (interface{}, error) {
 	}
 	return r, nil
 }
+
+type valueUser struct {
+	Name    string
+	Age     int32
+	Friends []*valueUser
+	Tags    map[string]int64
+}
+
+func (valueUser) JavaClassName() string {
+	return "com.bdt.info.ValueUser"
+}
+
+func doTestDecodeValue(t *testing.T, in interface{}, out interface{}, expected interface{}) {
+	e := NewEncoder()
+	if err := e.Encode(in); err != nil {
+		t.Fatalf("encode(%#v) = error:%s", in, err)
+	}
+
+	err := NewDecoder(e.Buffer()).DecodeValue(out)
+	if err != nil {
+		t.Fatalf("DecodeValue(%T) = error:%s", out, err)
+	}
+	assert.Equal(t, expected, reflect.ValueOf(out).Elem().Interface())
+}
+
+func TestDecodeValue(t *testing.T) {
+	var i64 int64
+	doTestDecodeValue(t, int32(100), &i64, int64(100))
+
+	var pi *int
+	doTestDecodeValue(t, int64(-1), &pi, func() *int { i := -1; return &i }())
+
+	var f32 float32
+	doTestDecodeValue(t, int32(3), &f32, float32(3))
+
+	var s []string
+	doTestDecodeValue(t, []interface{}{"a", "b"}, &s, []string{"a", "b"})
+
+	var ss [][]int64
+	doTestDecodeValue(t, []interface{}{[]int32{1, 2}, []interface{}{}}, &ss, [][]int64{{1, 2}, {}})
+
+	var m map[string][]int64
+	doTestDecodeValue(t, map[string][]int32{"a": {1, 2}}, &m, map[string][]int64{"a": {1, 2}})
+
+	u := &valueUser{
+		Name:    "dubbo",
+		Age:     18,
+		Friends: []*valueUser{{Name: "go"}, {Name: "java"}},
+		Tags:    map[string]int64{"star": 10000},
+	}
+	var users []*valueUser
+	doTestDecodeValue(t, []*valueUser{u}, &users, []*valueUser{u})
+
+	var mu map[string]valueUser
+	doTestDecodeValue(t, map[string]*valueUser{"dubbo": u}, &mu, map[string]valueUser{"dubbo": *u})
+
+	// untyped map to struct
+	var u2 valueUser
+	doTestDecodeValue(t, map[string]interface{}{"name": "dubbo", "age": int64(18)}, &u2, valueUser{Name: "dubbo", Age: 18})
+
+	// object of unregistered java class to struct and map
+	obj := &JavaObject{ClassName: "test.Unknown", Fields: []string{"name", "age"}, Values: []interface{}{"dubbo", int32(18)}}
+	var objUser valueUser
+	doTestDecodeValue(t, obj, &objUser, valueUser{Name: "dubbo", Age: 18})
+	var mobj map[string]interface{}
+	doTestDecodeValue(t, obj, &mobj, map[string]interface{}{"name": "dubbo", "age": int32(18)})
+	next := &JavaObject{ClassName: "test.Node", Fields: []string{"name", "next"}, Values: []interface{}{"dubbo", nil}}
+	next.Values[1] = next
+	var objNode *valueNode
+	e := NewEncoder()
+	if err := e.Encode(next); err != nil {
+		t.Fatalf("Encode(%v) = error:%s", next, err)
+	}
+	if err := NewDecoder(e.Buffer()).DecodeValue(&objNode); err != nil {
+		t.Fatalf("DecodeValue(%T) = error:%s", objNode, err)
+	}
+	assert.Equal(t, "dubbo", objNode.Name)
+	assert.True(t, objNode.Next == objNode)
+
+	var i8 int8
+	e = NewEncoder()
+	e.Encode(int32(300))
+	assert.NotNil(t, NewDecoder(e.Buffer()).DecodeValue(&i8))
+	assert.NotNil(t, NewDecoder(e.Buffer()).DecodeValue(i8))
+
+	// circular reference
+	cycle := map[interface{}]interface{}{"name": "dubbo"}
+	cycle["next"] = cycle
+	e = NewEncoder()
+	if err := e.Encode(cycle); err != nil {
+		t.Fatalf("Encode(%v) = error:%s", cycle, err)
+	}
+	var node *valueNode
+	if err := NewDecoder(e.Buffer()).DecodeValue(&node); err != nil {
+		t.Fatalf("DecodeValue(%T) = error:%s", node, err)
+	}
+	assert.Equal(t, "dubbo", node.Name)
+	assert.True(t, node.Next == node)
+}
+
+type valueNode struct {
+	Name string
+	Next *valueNode
+}
+
+func TestDecoderFromReader(t *testing.T) {
+	values := []interface{}{
+		int32(1),
+		"hello",
+		int64(1) << 40,
+		3.1415,
+		[]byte{1, 2, 3},
+		true,
+		time.Unix(1558358040, 0).UTC(),
+		&valueUser{Name: "dubbo", Age: 18, Tags: map[string]int64{"star": 10000}},
+	}
+
+	e := NewEncoder()
+	for _, v := range values {
+		if err := e.Encode(v); err != nil {
+			t.Fatalf("encode(%#v) = error:%s", v, err)
+		}
+	}
+
+	d := NewDecoderFromReader(iotest.OneByteReader(bytes.NewReader(e.Buffer())))
+	for _, v := range values {
+		out := reflect.New(reflect.TypeOf(v))
+		if err := d.DecodeValue(out.Interface()); err != nil {
+			t.Fatalf("DecodeValue(%T) = error:%s", v, err)
+		}
+		assert.Equal(t, v, out.Elem().Interface())
+	}
+	_, err := d.Decode()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestDecodeTruncated(t *testing.T) {
+	e := NewEncoder()
+	err := e.Encode([]interface{}{
+		"hello, world",
+		int64(1) << 40,
+		3.1415,
+		[]byte{1, 2, 3},
+		time.Date(2019, 5, 20, 13, 14, 0, 0, time.UTC),
+		map[interface{}]interface{}{"dubbo": int32(1)},
+		&valueUser{Name: "dubbo", Friends: []*valueUser{{Name: "go"}}},
+	})
+	if err != nil {
+		t.Fatalf("Encode() = error:%s", err)
+	}
+
+	buf := e.Buffer()
+	for i := 1; i < len(buf); i++ {
+		_, err = NewDecoderFromReader(bytes.NewReader(buf[:i])).Decode()
+		if jerrors.Cause(err) != io.ErrUnexpectedEOF {
+			t.Errorf("Decode(buf[:%d]) = error:%v, want io.ErrUnexpectedEOF", i, err)
+		}
+	}
+}
+
+func TestDecoderReset(t *testing.T) {
+	u := &valueUser{Name: "dubbo", Age: 18}
+
+	e := NewEncoder()
+	if err := e.Encode([]*valueUser{u, u}); err != nil {
+		t.Fatalf("Encode() = error:%s", err)
+	}
+
+	d := NewDecoder(nil)
+	for i := 0; i < 2; i++ {
+		d.Reset(e.Buffer())
+		var users []*valueUser
+		if err := d.DecodeValue(&users); err != nil {
+			t.Fatalf("DecodeValue() = error:%s", err)
+		}
+		assert.Equal(t, []*valueUser{u, u}, users)
+		_, err := d.Decode()
+		assert.Equal(t, io.EOF, err)
+	}
+}
@@ -12,4 +12,30 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Package hessian implements the hessian 2.0 serialization protocol and the dubbo codec.
+//
+// Decode returns the java lists as follows:
+//
+//   - untyped lists and java.util.ArrayList are decoded as []interface{}
+//   - the arrays of java primitives, strings and registered POJOs are decoded as the go
+//     slices of their types, eg: "[string" as []string, "[int" as []int32,
+//     "[com.test.User" as []*User
+//   - the java sets, eg: java.util.HashSet and java.util.TreeSet, are decoded as JavaSet
+//   - the other typed lists, eg: java.util.LinkedList and "[object", are decoded as JavaList
+//
+// JavaList and JavaSet keep the java class, so that the decoded value can be encoded again
+// as the same java type. JavaList.List and JavaSet.Values hold the go slice, and
+// Decoder.DecodeValue converts them into any go slice type.
+//
+// And the java maps as follows:
+//
+//   - untyped maps, eg: java.util.HashMap, are decoded as map[interface{}]interface{}
+//   - the typed maps of java classes registered by RegisterMapType are decoded as
+//     the registered go map types
+//   - the typed maps of registered POJO classes, written by hessian 1.0, are decoded as the POJOs
+//   - the other typed maps, eg: java.util.LinkedHashMap and java.util.TreeMap, are
+//     decoded as JavaMap, whose Map is a map[interface{}]interface{}
+//
+// JavaMap keeps the java class and the key order, so that it can be encoded again
+// as the same java type.
 package hessian
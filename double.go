@@ -34,31 +34,49 @@ import (
 // ::= x5d b0                # byte cast to double (-128.0 to 127.0)
 // ::= x5e b1 b0             # short cast to double
 // ::= x5f b3 b2 b1 b0       # 32-bit float cast to double
+//
+// encFloat picks the shortest form just like java Hessian2Output.writeDouble,
+// whose x5f form is the double value in milliunits, eg: 0.001.
 func encFloat(b []byte, v float64) []byte {
-	fv := float64(int64(v))
-	if fv == v {
-		iv := int64(v)
-		switch iv {
-		case 0:
+	iv := javaDoubleToInt(v)
+	if float64(iv) == v {
+		switch {
+		case iv == 0:
 			return encByte(b, BC_DOUBLE_ZERO)
-		case 1:
+		case iv == 1:
 			return encByte(b, BC_DOUBLE_ONE)
-		}
-		if iv >= -0x80 && iv < 0x80 {
+		case iv >= -0x80 && iv < 0x80:
 			return encByte(b, BC_DOUBLE_BYTE, byte(iv))
-		} else if iv >= -0x8000 && iv < 0x8000 {
-			return encByte(b, BC_DOUBLE_BYTE, byte(iv>>8), byte(iv))
+		case iv >= -0x8000 && iv < 0x8000:
+			return encByte(b, BC_DOUBLE_SHORT, byte(iv>>8), byte(iv))
 		}
+	}
 
-		goto END
+	mills := javaDoubleToInt(v * 1000)
+	if 0.001*float64(mills) == v {
+		return encByte(b, BC_DOUBLE_MILL, byte(mills>>24), byte(mills>>16), byte(mills>>8), byte(mills))
 	}
 
-END:
 	bits := uint64(math.Float64bits(v))
 	return encByte(b, BC_DOUBLE, byte(bits>>56), byte(bits>>48), byte(bits>>40),
 		byte(bits>>32), byte(bits>>24), byte(bits>>16), byte(bits>>8), byte(bits))
 }
 
+// javaDoubleToInt converts @v to int32 like the java cast (int) @v,
+// which returns 0 for NaN and saturates the out of range values.
+func javaDoubleToInt(v float64) int32 {
+	switch {
+	case math.IsNaN(v):
+		return 0
+	case v >= math.MaxInt32:
+		return math.MaxInt32
+	case v <= math.MinInt32:
+		return math.MinInt32
+	}
+
+	return int32(v)
+}
+
 /////////////////////////////////////////
 // Double
 /////////////////////////////////////////
@@ -70,7 +88,7 @@ END:
 // ::= x5d b0                # byte cast to double (-128.0 to 127.0)
 // ::= x5e b1 b0             # short cast to double
 // ::= x5f b3 b2 b1 b0       # 32-bit float cast to double
-func (d *Decoder) decDouble(flag int32) (interface{}, error) {
+func (d *Decoder) decDouble(flag int32) (float64, error) {
 	var (
 		err error
 		tag byte
@@ -79,11 +97,15 @@ func (d *Decoder) decDouble(flag int32) (interface{}, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return 0, jerrors.Trace(err)
+		}
 	}
 	switch tag {
 	case BC_LONG_INT:
-		return d.decInt32(TAG_READ)
+		i32, err := d.decInt32(TAG_READ)
+		return float64(i32), err
 
 	case BC_DOUBLE_ZERO:
 		return float64(0), nil
@@ -104,7 +126,7 @@ func (d *Decoder) decDouble(flag int32) (interface{}, error) {
 	case BC_DOUBLE_MILL:
 		var i32 int32
 		err = binary.Read(d.reader, binary.BigEndian, &i32)
-		return float64(i32) / 1000, jerrors.Trace(err)
+		return 0.001 * float64(i32), jerrors.Trace(err)
 
 	case BC_DOUBLE:
 		var f64 float64
@@ -112,5 +134,5 @@ func (d *Decoder) decDouble(flag int32) (interface{}, error) {
 		return f64, jerrors.Trace(err)
 	}
 
-	return nil, jerrors.Errorf("decDouble parse double wrong tag:%d-%#x", int(tag), tag)
+	return 0, jerrors.Errorf("decDouble parse double wrong tag:%d-%#x", int(tag), tag)
 }
@@ -15,6 +15,7 @@
 package hessian
 
 import (
+	"math"
 	"testing"
 )
 
@@ -72,3 +73,70 @@ func TestDouble(t *testing.T) {
 	testDoubleFramework(t, "replyDouble_m129_0", -129.0)
 	testDoubleFramework(t, "replyDouble_m32768_0", -32768.0)
 }
+
+func TestEncDoubleCompact(t *testing.T) {
+	cases := []struct {
+		v        float64
+		expected []byte
+	}{
+		{0, []byte{BC_DOUBLE_ZERO}},
+		{math.Copysign(0, -1), []byte{BC_DOUBLE_ZERO}},
+		{1, []byte{BC_DOUBLE_ONE}},
+		{-128, []byte{BC_DOUBLE_BYTE, 0x80}},
+		{127, []byte{BC_DOUBLE_BYTE, 0x7f}},
+		{128, []byte{BC_DOUBLE_SHORT, 0x00, 0x80}},
+		{-32768, []byte{BC_DOUBLE_SHORT, 0x80, 0x00}},
+		{32768, []byte{BC_DOUBLE_MILL, 0x01, 0xf4, 0x00, 0x00}},
+		{0.001, []byte{BC_DOUBLE_MILL, 0x00, 0x00, 0x00, 0x01}},
+		{-0.001, []byte{BC_DOUBLE_MILL, 0xff, 0xff, 0xff, 0xff}},
+		{65.536, []byte{BC_DOUBLE_MILL, 0x00, 0x01, 0x00, 0x00}},
+		{3.14159, []byte{BC_DOUBLE, 0x40, 0x09, 0x21, 0xf9, 0xf0, 0x1b, 0x86, 0x6e}},
+		{1e10, []byte{BC_DOUBLE, 0x42, 0x02, 0xa0, 0x5f, 0x20, 0x00, 0x00, 0x00}},
+	}
+
+	for _, c := range cases {
+		e := NewEncoder()
+		e.Encode(c.v)
+		assertEqual(c.expected, e.Buffer(), t)
+
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil || res != c.v {
+			t.Errorf("decode %v, got %v, %v", c.v, res, err)
+		}
+	}
+
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		e := NewEncoder()
+		e.Encode(v)
+		if e.Buffer()[0] != BC_DOUBLE {
+			t.Errorf("encode %v = %v", v, e.Buffer())
+		}
+	}
+}
+
+func TestDoubleArg(t *testing.T) {
+	values := map[string]float64{
+		"Double_0_0":      0.0,
+		"Double_0_001":    0.001,
+		"Double_1_0":      1.0,
+		"Double_127_0":    127.0,
+		"Double_128_0":    128.0,
+		"Double_2_0":      2.0,
+		"Double_3_14159":  3.14159,
+		"Double_32767_0":  32767.0,
+		"Double_65_536":   65.536,
+		"Double_m0_001":   -0.001,
+		"Double_m128_0":   -128.0,
+		"Double_m129_0":   -129.0,
+		"Double_m32768_0": -32768.0,
+	}
+
+	for name, v := range values {
+		testJavaDecode(t, "arg"+name, v)
+
+		// byte for byte the same as java
+		e := NewEncoder()
+		e.Encode(v)
+		assertEqual(getReply("reply"+name), e.Buffer(), t)
+	}
+}
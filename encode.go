@@ -33,6 +33,9 @@
 package hessian
 
 import (
+	"io"
+	"math"
+	"math/big"
 	"reflect"
 	"time"
 	"unsafe"
@@ -42,14 +45,22 @@ import (
 	jerrors "github.com/juju/errors"
 )
 
-// nil bool int8 int32 int64 float32 float64 time.Time
+// nil bool int8 int16 int32 int int64 uint8 uint16 uint32 uint uint64
+// float32 float64 time.Time
 // string []byte []interface{} map[interface{}]interface{}
 // array object struct
 
+// the buffered bytes size over which a stream encoder writes to its writer
+const encoderBufferSize = 8 * 1024
+
 type Encoder struct {
 	classInfoList []classInfo
 	buffer        []byte
 	refMap        map[unsafe.Pointer]_refElem
+	writer        io.Writer
+	err           error // the first error of writer
+	untypedStruct bool
+	typeRefs      map[string]int // java type --> type ref index
 }
 
 func NewEncoder() *Encoder {
@@ -61,16 +72,99 @@ func NewEncoder() *Encoder {
 	}
 }
 
+// NewEncoderTo creates a stream encoder which writes the encoded data to @w
+// whenever its buffer is full. Flush should be called after the last value is encoded.
+func NewEncoderTo(w io.Writer) *Encoder {
+	var buffer = make([]byte, encoderBufferSize)
+
+	return &Encoder{
+		buffer: buffer[:0],
+		refMap: make(map[unsafe.Pointer]_refElem, 7),
+		writer: w,
+	}
+}
+
+// SetUntypedStruct sets whether a go struct which has no java class name should be
+// encoded as an untyped map whose keys are the java field names. Such structs can not
+// be encoded by default. The java class name of a struct which does not implement
+// POJO can be set by RegisterPOJOMapping, or by the hessian tag of its blank field.
+func (e *Encoder) SetUntypedStruct(untyped bool) {
+	e.untypedStruct = untyped
+}
+
+// Reset clears the encoded data, the references and the class definitions of @e,
+// so that it can be reused to encode a new message. The allocated buffer is kept.
+func (e *Encoder) Reset() {
+	for i := range e.classInfoList {
+		e.classInfoList[i] = classInfo{}
+	}
+	e.classInfoList = e.classInfoList[:0]
+	e.buffer = e.buffer[:0]
+	for k := range e.refMap {
+		delete(e.refMap, k)
+	}
+	for k := range e.typeRefs {
+		delete(e.typeRefs, k)
+	}
+	e.err = nil
+}
+
+// Buffer returns the encoded data which has not been written to the writer.
 func (e *Encoder) Buffer() []byte {
 	return e.buffer[:]
 }
 
+// Flush writes the buffered data to the writer of a stream encoder.
+func (e *Encoder) Flush() error {
+	if e.writer == nil || e.err != nil {
+		return e.err
+	}
+
+	if len(e.buffer) > 0 {
+		_, e.err = e.writer.Write(e.buffer)
+		e.buffer = e.buffer[:0]
+	}
+
+	return jerrors.Trace(e.err)
+}
+
+func (e *Encoder) flushIfFull() error {
+	if e.writer != nil && len(e.buffer) >= encoderBufferSize {
+		return e.Flush()
+	}
+
+	return e.err
+}
+
+// encType encodes java type @typ of list or map, or its ref index if it has been encoded,
+// just like java Hessian2Output.writeType.
+func (e *Encoder) encType(typ string) {
+	if n, ok := e.typeRefs[typ]; ok {
+		e.buffer = encInt32(e.buffer, int32(n))
+		return
+	}
+
+	if e.typeRefs == nil {
+		e.typeRefs = make(map[string]int)
+	}
+	e.typeRefs[typ] = len(e.typeRefs)
+	e.buffer = encString(e.buffer, typ)
+}
+
 func (e *Encoder) Append(buf []byte) {
 	e.buffer = append(e.buffer, buf[:]...)
 }
 
 // If @v can not be encoded, the return value is nil. At present only struct may can not be encoded.
 func (e *Encoder) Encode(v interface{}) error {
+	if err := e.encValue(v); err != nil {
+		return err
+	}
+
+	return e.flushIfFull()
+}
+
+func (e *Encoder) encValue(v interface{}) error {
 	if v == nil {
 		e.buffer = encNull(e.buffer)
 		return nil
@@ -85,14 +179,36 @@ func (e *Encoder) Encode(v interface{}) error {
 		e.buffer = encBool(e.buffer, v.(bool))
 
 	case int8:
-		e.buffer = encInt32(e.buffer, v.(int32))
+		e.buffer = encInt32(e.buffer, int32(v.(int8)))
 
 	case int16:
-		e.buffer = encInt32(e.buffer, v.(int32))
+		e.buffer = encInt32(e.buffer, int32(v.(int16)))
 
 	case int32:
 		e.buffer = encInt32(e.buffer, v.(int32))
 
+	case uint8:
+		e.buffer = encInt32(e.buffer, int32(v.(uint8)))
+
+	case uint16:
+		e.buffer = encInt32(e.buffer, int32(v.(uint16)))
+
+	case uint32:
+		// uint32 may be larger than java int
+		e.buffer = encInt64(e.buffer, int64(v.(uint32)))
+
+	case uint:
+		if uint64(v.(uint)) > math.MaxInt64 {
+			return jerrors.Errorf("uint %d overflows java long", v.(uint))
+		}
+		e.buffer = encInt64(e.buffer, int64(v.(uint)))
+
+	case uint64:
+		if v.(uint64) > math.MaxInt64 {
+			return jerrors.Errorf("uint64 %d overflows java long", v.(uint64))
+		}
+		e.buffer = encInt64(e.buffer, int64(v.(uint64)))
+
 	case int:
 		// if v.(int) >= -2147483648 && v.(int) <= 2147483647 {
 		// 	b = encInt32(int32(v.(int)), b)
@@ -106,8 +222,7 @@ func (e *Encoder) Encode(v interface{}) error {
 		e.buffer = encInt64(e.buffer, v.(int64))
 
 	case time.Time:
-		e.buffer = encDateInMs(e.buffer, v.(time.Time))
-		// e.buffer = encDateInMimute(v.(time.Time), e.buffer)
+		e.buffer = encDate(e.buffer, v.(time.Time))
 
 	case float32:
 		e.buffer = encFloat(e.buffer, float64(v.(float32)))
@@ -116,30 +231,115 @@ func (e *Encoder) Encode(v interface{}) error {
 		e.buffer = encFloat(e.buffer, v.(float64))
 
 	case string:
-		e.buffer = encString(e.buffer, v.(string))
+		if e.writer == nil {
+			e.buffer = encString(e.buffer, v.(string))
+			break
+		}
+		// flush big string chunk by chunk
+		for s := v.(string); ; {
+			e.buffer, s = encStringChunk(e.buffer, s)
+			if s == "" {
+				break
+			}
+			if err := e.flushIfFull(); err != nil {
+				return err
+			}
+		}
 
 	case []byte:
-		e.buffer = encBinary(e.buffer, v.([]byte))
+		if e.writer == nil || len(v.([]byte)) == 0 {
+			e.buffer = encBinary(e.buffer, v.([]byte))
+			break
+		}
+		// flush big binary chunk by chunk
+		for b := v.([]byte); len(b) > 0; {
+			e.buffer, b = encBinaryChunk(e.buffer, b)
+			if err := e.flushIfFull(); err != nil {
+				return err
+			}
+		}
+
+	case *big.Int:
+		return e.encBigInt(v.(*big.Int))
+
+	case big.Int:
+		i := v.(big.Int)
+		return e.encBigInt(&i)
 
 	case map[interface{}]interface{}:
 		return e.encUntypedMap(v.(map[interface{}]interface{}))
 
+	case JavaObject:
+		obj := v.(JavaObject)
+		return e.encJavaObject(&obj)
+
+	case *JavaObject:
+		return e.encJavaObject(v.(*JavaObject))
+
+	case JavaThrowable:
+		t := v.(JavaThrowable)
+		return e.encJavaThrowable(&t)
+
+	case *JavaThrowable:
+		return e.encJavaThrowable(v.(*JavaThrowable))
+
+	case JavaList:
+		return e.encTypedList(v.(JavaList).List, v.(JavaList).Class)
+
+	case *JavaList:
+		if v.(*JavaList) == nil {
+			e.buffer = encNull(e.buffer)
+			return nil
+		}
+		return e.encTypedList(v.(*JavaList).List, v.(*JavaList).Class)
+
+	case JavaSet:
+		return e.encJavaSet(v.(JavaSet))
+
+	case *JavaSet:
+		if v.(*JavaSet) == nil {
+			e.buffer = encNull(e.buffer)
+			return nil
+		}
+		return e.encJavaSet(*v.(*JavaSet))
+
+	case JavaMap:
+		return e.encMap(v.(JavaMap).Map, v.(JavaMap).Class, v.(JavaMap).keys)
+
+	case *JavaMap:
+		if v.(*JavaMap) == nil {
+			e.buffer = encNull(e.buffer)
+			return nil
+		}
+		return e.encMap(v.(*JavaMap).Map, v.(*JavaMap).Class, v.(*JavaMap).keys)
+
 	default:
+		if vv := reflect.ValueOf(v); vv.Kind() == reflect.Ptr && vv.IsNil() {
+			e.buffer = encNull(e.buffer)
+			return nil
+		}
+
 		t := UnpackPtrType(reflect.TypeOf(v))
 		switch t.Kind() {
 		case reflect.Struct:
 			if p, ok := v.(POJO); ok {
-				return e.encObject(p)
+				return e.encObject(p, p.JavaClassName())
+			}
+			if javaName := getStructJavaName(t); javaName != "" {
+				return e.encObject(v, javaName)
+			}
+			if e.untypedStruct {
+				return e.encStructAsMap(v)
 			}
 
 			return jerrors.Errorf("struct type not Support! %s[%v] is not a instance of POJO!", t.String(), v)
 		case reflect.Slice, reflect.Array:
-			return e.encUntypedList(v)
+			return e.encList(v)
 		case reflect.Map: // 进入这个case，就说明map可能是map[string]int这种类型
-			return e.encMap(v)
+			return e.encMap(v, "", nil)
 		default:
 			if p, ok := v.(POJOEnum); ok { // JavaEnum
-				return e.encObject(p)
+				return e.encObject(p, p.JavaClassName())
 			}
 			return jerrors.Errorf("type not supported! %s", t.Kind().String())
 		}
@@ -16,6 +16,9 @@ package hessian
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -24,3 +27,85 @@ var assertEqual = func(want, got []byte, t *testing.T) {
 		t.Fatalf("want %v , got %v", want, got)
 	}
 }
+
+type limitWriter struct {
+	bytes.Buffer
+	maxWrite int
+	limit    int
+}
+
+func (w *limitWriter) Write(p []byte) (int, error) {
+	if len(p) > w.maxWrite {
+		w.maxWrite = len(p)
+	}
+	if w.limit > 0 && w.Len()+len(p) > w.limit {
+		return 0, errors.New("no space left")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestEncoderTo(t *testing.T) {
+	var (
+		users []*valueUser
+		bin   = make([]byte, 10*CHUNK_SIZE+10)
+		str   = strings.Repeat("dubbo-go 中文", CHUNK_SIZE)
+	)
+	for i := 0; i < 1000; i++ {
+		users = append(users, &valueUser{Name: fmt.Sprintf("user-%d", i), Age: int32(i)})
+	}
+	values := []interface{}{users, bin, str, int32(1)}
+
+	e := NewEncoder()
+	for _, v := range values {
+		if err := e.Encode(v); err != nil {
+			t.Fatalf("Encode(%T) = error:%v", v, err)
+		}
+	}
+
+	w := &limitWriter{}
+	se := NewEncoderTo(w)
+	for _, v := range values {
+		if err := se.Encode(v); err != nil {
+			t.Fatalf("Encode(%T) = error:%v", v, err)
+		}
+		if len(se.Buffer()) >= encoderBufferSize+4*CHUNK_SIZE+3 {
+			t.Fatalf("buffer size %d is not bounded", len(se.Buffer()))
+		}
+	}
+	if err := se.Flush(); err != nil {
+		t.Fatalf("Flush() = error:%v", err)
+	}
+	assertEqual(e.Buffer(), w.Bytes(), t)
+	if w.maxWrite >= encoderBufferSize+4*CHUNK_SIZE+3 {
+		t.Fatalf("max write size %d is not bounded", w.maxWrite)
+	}
+
+	w = &limitWriter{limit: encoderBufferSize}
+	se = NewEncoderTo(w)
+	if err := se.Encode(str); err == nil {
+		t.Fatalf("Encode() should return the write error")
+	}
+	if err := se.Flush(); err == nil {
+		t.Fatalf("Flush() should return the write error")
+	}
+}
+
+func TestEncoderReset(t *testing.T) {
+	u := &valueUser{Name: "dubbo", Age: 18}
+
+	e := NewEncoder()
+	if err := e.Encode([]*valueUser{u, u}); err != nil {
+		t.Fatalf("Encode() = error:%v", err)
+	}
+	want := append([]byte(nil), e.Buffer()...)
+
+	// the class definition and the reference of @u should be encoded again after Reset
+	e.Reset()
+	if len(e.Buffer()) != 0 {
+		t.Fatalf("buffer length after Reset is %d", len(e.Buffer()))
+	}
+	if err := e.Encode([]*valueUser{u, u}); err != nil {
+		t.Fatalf("Encode() = error:%v", err)
+	}
+	assertEqual(want, e.Buffer(), t)
+}
@@ -17,6 +17,7 @@ package hessian
 import (
 	"bufio"
 	"encoding/binary"
+	"sync"
 	"time"
 )
 
@@ -51,6 +52,43 @@ type Service struct {
 	Timeout   time.Duration // request timeout
 }
 
+// encoders whose buffer is larger than this are not put back to the pool,
+// so that a few huge packages do not pin their buffers in memory.
+const maxPooledBufferSize = 64 * 1024
+
+var (
+	encoderPool = sync.Pool{
+		New: func() interface{} { return NewEncoder() },
+	}
+	decoderPool = sync.Pool{
+		New: func() interface{} { return NewDecoder(nil) },
+	}
+)
+
+func getEncoder() *Encoder {
+	return encoderPool.Get().(*Encoder)
+}
+
+// putEncoder puts @e back to the pool. The buffer of @e must not be used any more.
+func putEncoder(e *Encoder) {
+	if cap(e.buffer) > maxPooledBufferSize {
+		return
+	}
+	e.Reset()
+	encoderPool.Put(e)
+}
+
+func getDecoder(b []byte) *Decoder {
+	d := decoderPool.Get().(*Decoder)
+	d.Reset(b)
+	return d
+}
+
+func putDecoder(d *Decoder) {
+	d.Reset(nil)
+	decoderPool.Put(d)
+}
+
 type HessianCodec struct {
 	pkgType PackgeType
 	reader  *bufio.Reader
@@ -181,6 +219,9 @@ func (h *HessianCodec) ReadBody(rspObj interface{}) error {
 	case Response:
 		if rspObj != nil {
 			if err = unpackResponseBody(buf, rspObj); err != nil {
+				if _, ok := err.(*JavaThrowable); ok {
+					return err
+				}
 				return jerrors.Trace(err)
 			}
 		}
@@ -103,4 +103,5 @@ func TestRequest(t *testing.T) {
 	doTest(t, Request, byte(0), []interface{}{3.2, true})
 	doTest(t, Request, byte(0), []interface{}{"a", 3, true, &Case{A: "a", B: 3}})
 	doTest(t, Request, byte(0), []interface{}{"a", 3, true, []*Case{{A: "a", B: 3}}})
+	doTest(t, Request, byte(0), []interface{}{[]string{"a"}, []int32{3}, []*Case{{A: "a", B: 3}}})
 }
@@ -63,7 +63,10 @@ func (d *Decoder) decInt32(flag int32) (int32, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return 0, jerrors.Trace(err)
+		}
 	}
 
 	switch {
@@ -117,3 +117,43 @@ func TestInt(t *testing.T) {
 	testIntFramework(t, "replyInt_m16", -16)
 	testIntFramework(t, "replyInt_m17", -17)
 }
+
+func TestEncIntKinds(t *testing.T) {
+	cases := []struct {
+		v        interface{}
+		expected int32
+	}{
+		{int8(-128), -128},
+		{int16(-0x8000), -0x8000},
+		{uint8(0xff), 0xff},
+		{uint16(0xffff), 0xffff},
+	}
+
+	for _, c := range cases {
+		e := NewEncoder()
+		if err := e.Encode(c.v); err != nil {
+			t.Fatalf("encode(%T) = error:%v", c.v, err)
+		}
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("decode(%T) = error:%v", c.v, err)
+		}
+		if res != c.expected {
+			t.Errorf("decode(%T(%v)) = %T(%v)", c.v, c.v, res, res)
+		}
+	}
+}
+
+func TestIntArg(t *testing.T) {
+	testJavaDecode(t, "argInt_0", int8(0))
+	testJavaDecode(t, "argInt_1", uint8(1))
+	testJavaDecode(t, "argInt_47", int16(47))
+	testJavaDecode(t, "argInt_m16", int8(-16))
+	testJavaDecode(t, "argInt_0x30", uint16(0x30))
+	testJavaDecode(t, "argInt_0x7ff", int16(0x7ff))
+	testJavaDecode(t, "argInt_0x800", uint16(0x800))
+	testJavaDecode(t, "argInt_m0x800", int16(-0x800))
+	testJavaDecode(t, "argInt_0x3ffff", int32(0x3ffff))
+	testJavaDecode(t, "argInt_0x7fffffff", int32(0x7fffffff))
+	testJavaDecode(t, "argInt_m0x80000000", int32(-0x80000000))
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2016 ~ 2019, Alex Stocks.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hessian
+
+import (
+	"fmt"
+	"time"
+)
+
+/////////////////////////////////////////
+// java.time
+/////////////////////////////////////////
+
+// Java hessian writes a java.time value as the object of its handle class, whose
+// readResolve method returns the java.time value, eg: java.time.LocalDate is written
+// as com.alibaba.com.caucho.hessian.io.java8.LocalDateHandle{year, month, day}.
+// The types below are the POJOs of these handle classes.
+
+const java8HandlePackage = "com.alibaba.com.caucho.hessian.io.java8."
+
+func init() {
+	RegisterPOJO(&LocalDate{})
+	RegisterPOJO(&LocalTime{})
+	RegisterPOJO(&LocalDateTime{})
+	RegisterPOJO(&Instant{})
+	RegisterPOJO(&Duration{})
+	RegisterPOJO(&ZoneOffset{})
+	RegisterPOJO(&ZonedDateTime{})
+}
+
+// LocalDate is java.time.LocalDate
+type LocalDate struct {
+	Year  int32 `hessian:"year"`
+	Month int32 `hessian:"month"`
+	Day   int32 `hessian:"day"`
+}
+
+func (LocalDate) JavaClassName() string {
+	return java8HandlePackage + "LocalDateHandle"
+}
+
+// NewLocalDate returns the date of @t in its location.
+func NewLocalDate(t time.Time) LocalDate {
+	year, month, day := t.Date()
+	return LocalDate{Year: int32(year), Month: int32(month), Day: int32(day)}
+}
+
+// ToTime returns the beginning of the date @d in location @loc.
+func (d LocalDate) ToTime(loc *time.Location) time.Time {
+	return time.Date(int(d.Year), time.Month(d.Month), int(d.Day), 0, 0, 0, 0, loc)
+}
+
+func (d LocalDate) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// LocalTime is java.time.LocalTime
+type LocalTime struct {
+	Hour   int32 `hessian:"hour"`
+	Minute int32 `hessian:"minute"`
+	Second int32 `hessian:"second"`
+	Nano   int32 `hessian:"nano"`
+}
+
+func (LocalTime) JavaClassName() string {
+	return java8HandlePackage + "LocalTimeHandle"
+}
+
+// NewLocalTime returns the time of day of @t in its location.
+func NewLocalTime(t time.Time) LocalTime {
+	hour, minute, second := t.Clock()
+	return LocalTime{Hour: int32(hour), Minute: int32(minute), Second: int32(second), Nano: int32(t.Nanosecond())}
+}
+
+// ToDuration returns the duration from midnight to @t.
+func (t LocalTime) ToDuration() time.Duration {
+	return time.Duration(t.Hour)*time.Hour + time.Duration(t.Minute)*time.Minute +
+		time.Duration(t.Second)*time.Second + time.Duration(t.Nano)
+}
+
+func (t LocalTime) String() string {
+	return fmt.Sprintf("%02d:%02d:%02d.%09d", t.Hour, t.Minute, t.Second, t.Nano)
+}
+
+// LocalDateTime is java.time.LocalDateTime
+type LocalDateTime struct {
+	Date LocalDate `hessian:"date"`
+	Time LocalTime `hessian:"time"`
+}
+
+func (LocalDateTime) JavaClassName() string {
+	return java8HandlePackage + "LocalDateTimeHandle"
+}
+
+// NewLocalDateTime returns the date and time of @t in its location.
+func NewLocalDateTime(t time.Time) LocalDateTime {
+	return LocalDateTime{Date: NewLocalDate(t), Time: NewLocalTime(t)}
+}
+
+// ToTime returns the time of @dt in location @loc.
+func (dt LocalDateTime) ToTime(loc *time.Location) time.Time {
+	d, t := dt.Date, dt.Time
+	return time.Date(int(d.Year), time.Month(d.Month), int(d.Day),
+		int(t.Hour), int(t.Minute), int(t.Second), int(t.Nano), loc)
+}
+
+func (dt LocalDateTime) String() string {
+	return dt.Date.String() + "T" + dt.Time.String()
+}
+
+// Instant is java.time.Instant
+type Instant struct {
+	Seconds int64 `hessian:"seconds"` // seconds from the epoch of 1970-01-01T00:00:00Z
+	Nanos   int32 `hessian:"nanos"`
+}
+
+func (Instant) JavaClassName() string {
+	return java8HandlePackage + "InstantHandle"
+}
+
+func NewInstant(t time.Time) Instant {
+	return Instant{Seconds: t.Unix(), Nanos: int32(t.Nanosecond())}
+}
+
+// ToTime returns the UTC time of @i.
+func (i Instant) ToTime() time.Time {
+	return time.Unix(i.Seconds, int64(i.Nanos)).UTC()
+}
+
+// Duration is java.time.Duration
+type Duration struct {
+	Seconds int64 `hessian:"seconds"`
+	Nanos   int32 `hessian:"nanos"` // always positive, just like java
+}
+
+func (Duration) JavaClassName() string {
+	return java8HandlePackage + "DurationHandle"
+}
+
+func NewDuration(d time.Duration) Duration {
+	seconds, nanos := int64(d/time.Second), int32(d%time.Second)
+	if nanos < 0 {
+		seconds--
+		nanos += int32(time.Second)
+	}
+	return Duration{Seconds: seconds, Nanos: nanos}
+}
+
+// ToDuration returns the go duration of @d, which overflows if @d is longer than about 292 years.
+func (d Duration) ToDuration() time.Duration {
+	return time.Duration(d.Seconds)*time.Second + time.Duration(d.Nanos)
+}
+
+// ZoneOffset is java.time.ZoneOffset
+type ZoneOffset struct {
+	Seconds int32 `hessian:"seconds"` // the total zone offset in seconds
+}
+
+func (ZoneOffset) JavaClassName() string {
+	return java8HandlePackage + "ZoneOffsetHandle"
+}
+
+// ID returns the java zone offset id, eg: Z, +08:00, -03:30:15.
+func (o ZoneOffset) ID() string {
+	if o.Seconds == 0 {
+		return "Z"
+	}
+
+	sign, seconds := '+', o.Seconds
+	if seconds < 0 {
+		sign, seconds = '-', -seconds
+	}
+	id := fmt.Sprintf("%c%02d:%02d", sign, seconds/3600, seconds/60%60)
+	if seconds%60 != 0 {
+		id += fmt.Sprintf(":%02d", seconds%60)
+	}
+
+	return id
+}
+
+// ZonedDateTime is java.time.ZonedDateTime
+type ZonedDateTime struct {
+	ZoneID   string        `hessian:"zoneId"`
+	DateTime LocalDateTime `hessian:"dateTime"`
+	Offset   ZoneOffset    `hessian:"offset"`
+}
+
+func (ZonedDateTime) JavaClassName() string {
+	return java8HandlePackage + "ZonedDateTimeHandle"
+}
+
+// NewZonedDateTime returns the ZonedDateTime of @t. The zone id is the name of the
+// location of @t, or the zone offset id if the location is time.Local.
+func NewZonedDateTime(t time.Time) ZonedDateTime {
+	_, offset := t.Zone()
+	z := ZonedDateTime{
+		DateTime: NewLocalDateTime(t),
+		Offset:   ZoneOffset{Seconds: int32(offset)},
+		ZoneID:   t.Location().String(),
+	}
+	if t.Location() == time.Local {
+		z.ZoneID = z.Offset.ID()
+	}
+
+	return z
+}
+
+// ToTime returns the time of @z. The location is loaded by the zone id,
+// or is a fixed zone of the zone offset if the zone id can not be loaded.
+func (z ZonedDateTime) ToTime() time.Time {
+	loc, err := time.LoadLocation(z.ZoneID)
+	if err != nil || z.ZoneID == "" {
+		loc = time.FixedZone(z.ZoneID, int(z.Offset.Seconds))
+	}
+
+	utc := z.DateTime.ToTime(time.UTC).Add(-time.Duration(z.Offset.Seconds) * time.Second)
+	return utc.In(loc)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2016 ~ 2019, Alex Stocks.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hessian
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	java8Shanghai, _ = time.LoadLocation("Asia/Shanghai")
+	java8Time        = time.Date(2019, 5, 20, 13, 14, 15, 123456789, java8Shanghai)
+
+	java8Values = map[string]interface{}{
+		"LocalDate":     NewLocalDate(java8Time),
+		"LocalTime":     NewLocalTime(java8Time),
+		"LocalDateTime": NewLocalDateTime(java8Time),
+		"Instant":       Instant{Seconds: 1558329255, Nanos: 123456789},
+		"Duration":      NewDuration(-90*time.Second + 5),
+		"ZonedDateTime": NewZonedDateTime(java8Time.Truncate(time.Second)),
+	}
+)
+
+func TestJava8TimeConvert(t *testing.T) {
+	assert.Equal(t, LocalDate{2019, 5, 20}, java8Values["LocalDate"])
+	assert.Equal(t, "2019-05-20", java8Values["LocalDate"].(LocalDate).String())
+	assert.Equal(t, LocalTime{13, 14, 15, 123456789}, java8Values["LocalTime"])
+	assert.Equal(t, "2019-05-20T13:14:15.123456789", java8Values["LocalDateTime"].(LocalDateTime).String())
+	assert.True(t, java8Time.Equal(java8Values["LocalDateTime"].(LocalDateTime).ToTime(java8Shanghai)))
+	assert.True(t, java8Time.Equal(NewInstant(java8Time).ToTime()))
+
+	// java normalizes the nanos of negative durations to be positive
+	assert.Equal(t, Duration{Seconds: -90, Nanos: 5}, java8Values["Duration"])
+	assert.Equal(t, -90*time.Second+5, java8Values["Duration"].(Duration).ToDuration())
+
+	z := java8Values["ZonedDateTime"].(ZonedDateTime)
+	assert.Equal(t, "Asia/Shanghai", z.ZoneID)
+	assert.Equal(t, "+08:00", z.Offset.ID())
+	assert.Equal(t, java8Time.Truncate(time.Second), z.ToTime())
+
+	z = NewZonedDateTime(time.Date(2019, 5, 20, 13, 14, 15, 0, time.FixedZone("", -(3*3600+30*60+15))))
+	assert.Equal(t, "-03:30:15", z.Offset.ID())
+	assert.True(t, z.ToTime().Equal(time.Date(2019, 5, 20, 16, 44, 30, 0, time.UTC)))
+	assert.Equal(t, "Z", ZoneOffset{}.ID())
+}
+
+func TestJava8TimeEncode(t *testing.T) {
+	for name, v := range java8Values {
+		e := NewEncoder()
+		if err := e.Encode(v); err != nil {
+			t.Fatalf("%s: encode(%#v) = error:%v", name, v, err)
+		}
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("%s: decode = error:%v", name, err)
+		}
+		assert.Equal(t, v, res.(reflect.Value).Elem().Interface(), name)
+	}
+}
+
+func TestJava8TimeJava(t *testing.T) {
+	for name, v := range java8Values {
+		r, err := decodeResponse("java8Reply" + name)
+		if err != nil {
+			t.Errorf("%s: decode fail with error %v", name, err)
+			continue
+		}
+		assert.Equal(t, v, r.(reflect.Value).Elem().Interface(), name)
+
+		testJavaDecode(t, "java8Arg"+name, v)
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright (c) 2016 ~ 2019, Alex Stocks.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hessian
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+/////////////////////////////////////////
+// java.lang.Throwable
+/////////////////////////////////////////
+
+const (
+	javaThrowable        = "java.lang.Throwable"
+	javaRuntimeException = "java.lang.RuntimeException"
+)
+
+// the fields of java.lang.Throwable written by java hessian ThrowableSerializer
+var javaThrowableFields = []string{"detailMessage", "cause", "stackTrace", "suppressedExceptions"}
+
+// the exception classes which are decoded as JavaThrowable without registration
+var javaExceptionClasses = []string{
+	"java.lang.Exception",
+	"java.lang.RuntimeException",
+	"java.lang.Error",
+	"java.lang.IllegalArgumentException",
+	"java.lang.IllegalStateException",
+	"java.lang.NullPointerException",
+	"java.lang.UnsupportedOperationException",
+	"java.lang.IndexOutOfBoundsException",
+	"java.lang.ArrayIndexOutOfBoundsException",
+	"java.lang.ClassCastException",
+	"java.lang.ClassNotFoundException",
+	"java.lang.ArithmeticException",
+	"java.lang.NumberFormatException",
+	"java.lang.InterruptedException",
+	"java.io.IOException",
+	"java.util.NoSuchElementException",
+	"java.util.ConcurrentModificationException",
+	"java.util.concurrent.TimeoutException",
+	"java.util.concurrent.ExecutionException",
+	"com.alibaba.dubbo.rpc.RpcException",
+	"org.apache.dubbo.rpc.RpcException",
+}
+
+func init() {
+	RegisterPOJO(&StackTraceElement{})
+	RegisterPOJO(&JavaThrowable{})
+	for _, name := range javaExceptionClasses {
+		RegisterJavaException(name)
+	}
+}
+
+// StackTraceElement is java.lang.StackTraceElement
+type StackTraceElement struct {
+	DeclaringClass string `hessian:"declaringClass"`
+	MethodName     string `hessian:"methodName"`
+	FileName       string `hessian:"fileName"`
+	LineNumber     int32  `hessian:"lineNumber"` // -2 means a native method
+}
+
+func (StackTraceElement) JavaClassName() string {
+	return "java.lang.StackTraceElement"
+}
+
+// JavaThrowable is java.lang.Throwable and its subclasses. The fields of a subclass
+// other than those of java.lang.Throwable are skipped when decoding.
+type JavaThrowable struct {
+	ExceptionClass       string               `hessian:"-"` // java class name, java.lang.Throwable if empty
+	DetailMessage        string               `hessian:"detailMessage"`
+	Cause                *JavaThrowable       `hessian:"cause"`
+	StackTrace           []*StackTraceElement `hessian:"stackTrace"`
+	SuppressedExceptions []*JavaThrowable     `hessian:"suppressedExceptions"`
+}
+
+func (t JavaThrowable) JavaClassName() string {
+	if t.ExceptionClass == "" {
+		return javaThrowable
+	}
+	return t.ExceptionClass
+}
+
+// setJavaClass sets the decoded java exception class of @t.
+func (t *JavaThrowable) setJavaClass(javaName string) {
+	t.ExceptionClass = javaName
+	// java sets the cause of an exception to itself if it has no cause
+	if t.Cause == t {
+		t.Cause = nil
+	}
+}
+
+// Error returns the same string as java Throwable.toString().
+func (t *JavaThrowable) Error() string {
+	if t.DetailMessage == "" {
+		return t.JavaClassName()
+	}
+	return t.JavaClassName() + ": " + t.DetailMessage
+}
+
+// Unwrap returns the cause of @t, so that the cause chain can be inspected by errors.Is and errors.As.
+func (t *JavaThrowable) Unwrap() error {
+	if t.Cause == nil {
+		return nil
+	}
+	return t.Cause
+}
+
+// the max length of the cause chain built by NewJavaException, in case an error is its own cause
+const maxJavaCauseDepth = 64
+
+// NewJavaException converts go error @err into java exception @javaClassName, which is
+// java.lang.RuntimeException if empty. The errors unwrapped from @err, or the cause of a
+// juju/errors error, become the causes of java.lang.RuntimeException, and a *JavaThrowable
+// in the chain is kept as it is.
+func NewJavaException(javaClassName string, err error) *JavaThrowable {
+	if javaClassName == "" {
+		javaClassName = javaRuntimeException
+	}
+
+	t := &JavaThrowable{ExceptionClass: javaClassName, DetailMessage: err.Error()}
+	last := t
+	for depth := 0; depth < maxJavaCauseDepth; depth++ {
+		cause := unwrapError(err)
+		if cause == nil {
+			break
+		}
+		if c, ok := cause.(*JavaThrowable); ok {
+			last.Cause = c
+			break
+		}
+		last.Cause = &JavaThrowable{ExceptionClass: javaRuntimeException, DetailMessage: cause.Error()}
+		last, err = last.Cause, cause
+	}
+
+	return t
+}
+
+// unwrapError returns the cause of @err by errors.Unwrap, or by the Cause method of the
+// errors of juju/errors, which have no Unwrap method. The return value is nil if @err has no cause.
+func unwrapError(err error) error {
+	if cause := errors.Unwrap(err); cause != nil {
+		return cause
+	}
+
+	c, ok := err.(interface{ Cause() error })
+	if !ok {
+		return nil
+	}
+	// the cause of a juju/errors error without cause is nil or itself
+	cause := c.Cause()
+	if cause == nil || reflect.TypeOf(cause) == reflect.TypeOf(err) && reflect.TypeOf(err).Comparable() && cause == err {
+		return nil
+	}
+	return cause
+}
+
+// FillInStackTrace sets the stack trace of @t to the go call stack of its caller, just like
+// java Throwable.fillInStackTrace(). The declaring class of a go function is its package path,
+// with the receiver type if it is a method.
+func (t *JavaThrowable) FillInStackTrace() *JavaThrowable {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(2, pc)
+	// the stack may be truncated if the buffer is full
+	for n == len(pc) {
+		pc = make([]uintptr, 2*len(pc))
+		n = runtime.Callers(2, pc)
+	}
+	frames := runtime.CallersFrames(pc[:n])
+
+	t.StackTrace = nil
+	for {
+		f, more := frames.Next()
+		// eg: github.com/dubbogo/hessian2.(*Encoder).Encode
+		class, method := "", f.Function
+		if i := strings.LastIndexByte(f.Function, '/') + 1; strings.IndexByte(f.Function[i:], '.') >= 0 {
+			j := i + strings.LastIndexByte(f.Function[i:], '.')
+			class, method = f.Function[:j], f.Function[j+1:]
+		}
+		t.StackTrace = append(t.StackTrace, &StackTraceElement{
+			DeclaringClass: class,
+			MethodName:     method,
+			FileName:       filepath.Base(f.File),
+			LineNumber:     int32(f.Line),
+		})
+		if !more {
+			break
+		}
+	}
+
+	return t
+}
+
+// encJavaThrowable encodes @t the same as java hessian ThrowableSerializer.
+func (e *Encoder) encJavaThrowable(t *JavaThrowable) error {
+	if t == nil {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+
+	// check ref
+	if n, ok := e.checkRefMap(reflect.ValueOf(t)); ok {
+		e.buffer = encRef(e.buffer, n)
+		return nil
+	}
+
+	e.encObjectHead(t.JavaClassName(), javaThrowableFields)
+	if t.DetailMessage == "" {
+		e.buffer = encNull(e.buffer)
+	} else {
+		e.buffer = encString(e.buffer, t.DetailMessage)
+	}
+
+	// java sets the cause of an exception to itself if it has no cause
+	cause := t.Cause
+	if cause == nil {
+		cause = t
+	}
+	if err := e.encJavaThrowable(cause); err != nil {
+		return err
+	}
+
+	// java Throwable.printStackTrace() fails on a null stack trace
+	stackTrace := t.StackTrace
+	if stackTrace == nil {
+		stackTrace = []*StackTraceElement{}
+	}
+	if err := e.Encode(stackTrace); err != nil {
+		return err
+	}
+
+	if t.SuppressedExceptions == nil {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+	return e.Encode(JavaList{Class: javaArrayList, List: t.SuppressedExceptions})
+}
+
+// RegisterJavaException registers java exception class @javaClassName, whose objects
+// are decoded as *JavaThrowable.
+func RegisterJavaException(javaClassName string) {
+	pojoRegistry.Lock()
+	defer pojoRegistry.Unlock()
+	if s, ok := pojoRegistry.registry[pojoRegistry.j2g[javaThrowable]]; ok {
+		pojoRegistry.j2g[javaClassName] = s.goName
+	}
+}
+
+// isJavaThrowable checks whether class def @cls is written by java.lang.Throwable or one of
+// its subclasses, so that an unregistered exception can be decoded as *JavaThrowable.
+func isJavaThrowable(cls classInfo) bool {
+	var message, stackTrace bool
+	for _, f := range cls.fieldNameList {
+		switch f {
+		case "detailMessage":
+			message = true
+		case "stackTrace":
+			stackTrace = true
+		}
+	}
+
+	return message && stackTrace
+}
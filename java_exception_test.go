@@ -0,0 +1,273 @@
+// Copyright (c) 2016 ~ 2019, Alex Stocks.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hessian
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+import (
+	jerrors "github.com/juju/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+// newJavaException returns the object written by java for exception @class,
+// whose cause is itself if @cause is nil.
+func newJavaException(class, message string, cause *JavaObject, suppressed ...interface{}) *JavaObject {
+	obj := &JavaObject{
+		ClassName: class,
+		Fields:    javaThrowableFields,
+		Values: []interface{}{
+			message,
+			cause,
+			[]*StackTraceElement{{DeclaringClass: "test.Foo", MethodName: "bar", FileName: "Foo.java", LineNumber: 10}},
+			JavaList{Class: "java.util.Collections$UnmodifiableRandomAccessList", List: suppressed},
+		},
+	}
+	if cause == nil {
+		obj.Values[1] = obj
+	}
+
+	return obj
+}
+
+func TestJavaThrowable(t *testing.T) {
+	cause := newJavaException("java.lang.IllegalStateException", "illegal state", nil)
+	obj := newJavaException("java.lang.RuntimeException", "runtime", cause, newJavaException("java.io.IOException", "io", nil))
+	// the fields of an unregistered exception class other than those of java.lang.Throwable are skipped
+	biz := newJavaException("test.BizException", "biz", obj)
+	biz.Fields = append(biz.Fields, "code")
+	biz.Values = append(biz.Values, int32(500))
+
+	e := NewEncoder()
+	if err := e.Encode(biz); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", biz, err)
+	}
+	res, err := EnsureInterface(NewDecoder(e.Buffer()).Decode())
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+
+	bizErr, ok := res.(*JavaThrowable)
+	if !ok {
+		t.Fatalf("Decode() = %#v, want *JavaThrowable", res)
+	}
+	assert.Equal(t, "test.BizException: biz", bizErr.Error())
+
+	rt := bizErr.Cause
+	assert.Equal(t, "java.lang.RuntimeException", rt.JavaClassName())
+	assert.Equal(t, "runtime", rt.DetailMessage)
+	assert.Equal(t, []*StackTraceElement{{DeclaringClass: "test.Foo", MethodName: "bar", FileName: "Foo.java", LineNumber: 10}}, rt.StackTrace)
+	assert.Equal(t, 1, len(rt.SuppressedExceptions))
+	assert.Equal(t, "java.io.IOException: io", rt.SuppressedExceptions[0].Error())
+	assert.Nil(t, rt.SuppressedExceptions[0].Cause)
+
+	// the cause of an exception without cause is itself in java
+	assert.Equal(t, "java.lang.IllegalStateException: illegal state", rt.Cause.Error())
+	assert.Nil(t, rt.Cause.Cause)
+
+	var target *JavaThrowable
+	assert.True(t, errors.As(error(bizErr), &target))
+	assert.True(t, errors.Is(bizErr, rt.Cause))
+	assert.Nil(t, errors.Unwrap(rt.Cause))
+}
+
+func TestJavaThrowableResponse(t *testing.T) {
+	e := NewEncoder()
+	e.Encode(RESPONSE_WITH_EXCEPTION)
+	e.Encode(newJavaException("java.lang.RuntimeException", "runtime", nil))
+
+	var rsp interface{}
+	err := unpackResponseBody(e.Buffer(), &rsp)
+
+	var expt *JavaThrowable
+	if !errors.As(err, &expt) {
+		t.Fatalf("unpackResponseBody() = error:%v, want *JavaThrowable", err)
+	}
+	assert.Equal(t, "java.lang.RuntimeException", expt.ExceptionClass)
+	assert.Equal(t, "runtime", expt.DetailMessage)
+}
+
+func TestJavaThrowableJava(t *testing.T) {
+	res, err := EnsureInterface(decodeResponse("customReplyThrowable"))
+	if err != nil {
+		t.Fatalf("decode fail with error %v", err)
+	}
+	expt, ok := res.(*JavaThrowable)
+	if !ok {
+		t.Fatalf("customReplyThrowable = %#v, want *JavaThrowable", res)
+	}
+	assert.Equal(t, "java.lang.Throwable: throwable", expt.Error())
+	assert.Nil(t, expt.Cause)
+	assert.NotEmpty(t, expt.StackTrace)
+
+	res, err = EnsureInterface(decodeResponse("customReplyRuntimeException"))
+	if err != nil {
+		t.Fatalf("decode fail with error %v", err)
+	}
+	expt, ok = res.(*JavaThrowable)
+	if !ok {
+		t.Fatalf("customReplyRuntimeException = %#v, want *JavaThrowable", res)
+	}
+	assert.Equal(t, "java.lang.RuntimeException: runtime", expt.Error())
+	assert.Equal(t, "java.lang.IllegalStateException: illegal state", expt.Cause.Error())
+	assert.Equal(t, "customReplyRuntimeException", expt.StackTrace[0].MethodName)
+	assert.Equal(t, 1, len(expt.SuppressedExceptions))
+	assert.Equal(t, "java.io.IOException: io", expt.SuppressedExceptions[0].Error())
+}
+
+func TestEncJavaThrowable(t *testing.T) {
+	cause := &JavaThrowable{ExceptionClass: "java.lang.IllegalStateException", DetailMessage: "illegal state"}
+	expt := NewJavaException("", fmt.Errorf("wrap: %w", cause))
+	assert.Equal(t, "java.lang.RuntimeException: wrap: java.lang.IllegalStateException: illegal state", expt.Error())
+	assert.True(t, expt.Cause == cause)
+
+	// encoded the same as the object written by java
+	stackTrace := []*StackTraceElement{{DeclaringClass: "test.Foo", MethodName: "bar", FileName: "Foo.java", LineNumber: 10}}
+	expt.StackTrace = stackTrace
+	expt.SuppressedExceptions = []*JavaThrowable{}
+	causeObj := &JavaObject{ClassName: cause.ExceptionClass, Fields: javaThrowableFields}
+	causeObj.Values = []interface{}{"illegal state", causeObj, []*StackTraceElement{}, nil}
+	obj := &JavaObject{
+		ClassName: "java.lang.RuntimeException",
+		Fields:    javaThrowableFields,
+		Values:    []interface{}{"wrap: java.lang.IllegalStateException: illegal state", causeObj, stackTrace, JavaList{Class: "java.util.ArrayList", List: []interface{}{}}},
+	}
+
+	e := NewEncoder()
+	if err := e.Encode(expt); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", expt, err)
+	}
+	want := NewEncoder()
+	if err := want.Encode(obj); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", obj, err)
+	}
+	assertEqual(want.Buffer(), e.Buffer(), t)
+
+	res, err := EnsureInterface(NewDecoder(e.Buffer()).Decode())
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+	decoded := res.(*JavaThrowable)
+	assert.Equal(t, expt.Error(), decoded.Error())
+	assert.Equal(t, cause.Error(), decoded.Cause.Error())
+	assert.Equal(t, stackTrace, decoded.StackTrace)
+	assert.Empty(t, decoded.SuppressedExceptions)
+
+	expt = NewJavaException("com.test.BizException", errors.New("biz")).FillInStackTrace()
+	assert.Equal(t, "com.test.BizException: biz", expt.Error())
+	assert.Nil(t, expt.Cause)
+	assert.Equal(t, &StackTraceElement{
+		DeclaringClass: "github.com/dubbogo/hessian2",
+		MethodName:     "TestEncJavaThrowable",
+		FileName:       "java_exception_test.go",
+		LineNumber:     expt.StackTrace[0].LineNumber,
+	}, expt.StackTrace[0])
+}
+
+// deepStackTrace fills the stack trace of an exception under @depth nested calls.
+func deepStackTrace(depth int) *JavaThrowable {
+	if depth == 0 {
+		return NewJavaException("", errors.New("deep")).FillInStackTrace()
+	}
+	return deepStackTrace(depth - 1)
+}
+
+func TestFillInStackTrace(t *testing.T) {
+	// deeper than the initial buffer of runtime.Callers
+	expt := deepStackTrace(100)
+	depth := 0
+	for _, e := range expt.StackTrace {
+		if e.MethodName == "deepStackTrace" {
+			depth++
+		}
+	}
+	assert.Equal(t, 101, depth)
+	assert.Equal(t, "goexit", expt.StackTrace[len(expt.StackTrace)-1].MethodName)
+}
+
+func TestJavaExceptionCause(t *testing.T) {
+	// juju/errors has no Unwrap, the cause is got by jerrors.Cause
+	base := errors.New("base")
+	expt := NewJavaException("", jerrors.Annotate(base, "ann"))
+	assert.Equal(t, "java.lang.RuntimeException: ann: base", expt.Error())
+	if assert.NotNil(t, expt.Cause) {
+		assert.Equal(t, "java.lang.RuntimeException: base", expt.Cause.Error())
+		assert.Nil(t, expt.Cause.Cause)
+	}
+	expt = NewJavaException("", jerrors.Trace(base))
+	assert.Equal(t, "java.lang.RuntimeException: base", expt.Cause.Error())
+
+	expt = NewJavaException("", jerrors.New("no cause"))
+	assert.Nil(t, expt.Cause)
+	expt = NewJavaException("", base)
+	assert.Nil(t, expt.Cause)
+
+	// an error of non-comparable type which is its own cause
+	expt = NewJavaException("", multiErr{base, errors.New("another")})
+	assert.Equal(t, "java.lang.RuntimeException: base; another", expt.Error())
+	assert.Nil(t, expt.Cause)
+	expt = NewJavaException("", selfCauseErr{base})
+	depth := 0
+	for c := expt.Cause; c != nil; c = c.Cause {
+		depth++
+	}
+	assert.Equal(t, maxJavaCauseDepth, depth)
+}
+
+type multiErr []error
+
+func (m multiErr) Error() string {
+	s := make([]string, 0, len(m))
+	for _, err := range m {
+		s = append(s, err.Error())
+	}
+	return strings.Join(s, "; ")
+}
+
+type selfCauseErr multiErr
+
+func (e selfCauseErr) Error() string {
+	return multiErr(e).Error()
+}
+
+func (e selfCauseErr) Cause() error {
+	return e
+}
+
+func TestPackJavaThrowable(t *testing.T) {
+	header := DubboHeader{SerialID: 2, Type: Response, ID: 1}
+	buf, err := packResponse(header, nil, fmt.Errorf("wrap: %w", errors.New("cause")))
+	if err != nil {
+		t.Fatalf("packResponse() = error:%s", err)
+	}
+
+	var rsp interface{}
+	err = unpackResponseBody(buf[HEADER_LENGTH:], &rsp)
+	var expt *JavaThrowable
+	if !errors.As(err, &expt) {
+		t.Fatalf("unpackResponseBody() = error:%v, want *JavaThrowable", err)
+	}
+	assert.Equal(t, "java.lang.RuntimeException: wrap: cause", expt.Error())
+	assert.Equal(t, "java.lang.RuntimeException: cause", expt.Cause.Error())
+}
+
+func TestEncJavaThrowableJava(t *testing.T) {
+	cause := &JavaThrowable{ExceptionClass: "java.lang.IllegalStateException", DetailMessage: "illegal state"}
+	testJavaDecode(t, "customArgRuntimeException", NewJavaException("", fmt.Errorf("wrap: %w", cause)).FillInStackTrace())
+}
@@ -17,12 +17,56 @@ package hessian
 import (
 	"io"
 	"reflect"
+	"strings"
 )
 
 import (
 	jerrors "github.com/juju/errors"
 )
 
+var (
+	_stringType    = reflect.TypeOf("")
+	_boolType      = reflect.TypeOf(false)
+	_intType       = reflect.TypeOf(int(0))
+	_int32Type     = reflect.TypeOf(int32(0))
+	_int64Type     = reflect.TypeOf(int64(0))
+	_float32Type   = reflect.TypeOf(float32(0))
+	_float64Type   = reflect.TypeOf(float64(0))
+	_interfaceType = reflect.TypeOf((*interface{})(nil)).Elem()
+)
+
+const (
+	javaArrayList = "java.util.ArrayList"
+	javaHashSet   = "java.util.HashSet"
+)
+
+// the java set classes, whose typed lists are decoded as JavaSet
+var javaSetClasses = map[string]bool{
+	javaHashSet:               true,
+	"java.util.LinkedHashSet": true,
+	"java.util.TreeSet":       true,
+	"java.util.concurrent.CopyOnWriteArraySet":   true,
+	"java.util.concurrent.ConcurrentSkipListSet": true,
+}
+
+// JavaList is a go slice with its java list class or array type, which is encoded
+// as a typed list, eg: JavaList{Class: "java.util.LinkedList", List: []string{"a"}},
+// or JavaList{Class: ARRAY_OBJECT, List: []interface{}{"a"}} for java Object[].
+// The typed lists whose element type has no go type, except java.util.ArrayList,
+// are decoded as JavaList, so that they can be encoded again with the same type.
+type JavaList struct {
+	Class string      // java list class or array type
+	List  interface{} // go slice or array
+}
+
+// JavaSet is a java set, which is encoded as a typed list of its java set class, eg:
+// JavaSet{Class: "java.util.TreeSet", Values: []string{"a", "b"}}.
+// The typed lists of java set classes are decoded as JavaSet whose Values is a []interface{}.
+type JavaSet struct {
+	Class  string      // java set class, java.util.HashSet if empty
+	Values interface{} // go slice or array, or go map whose keys are the values of the set
+}
+
 /////////////////////////////////////////
 // List
 /////////////////////////////////////////
@@ -34,12 +78,114 @@ import (
 // ::= x58 int value*        # fixed-length untyped list
 // ::= [x70-77] type value*  # fixed-length typed list
 // ::= [x78-7f] value*       # fixed-length untyped list
+func (e *Encoder) encList(v interface{}) error {
+	if listType := getListType(UnpackPtrType(reflect.TypeOf(v))); listType != "" {
+		return e.encTypedList(v, listType)
+	}
+
+	return e.encUntypedList(v)
+}
+
+// getListType returns the java array type of go slice or array type @typ, just like
+// java ArraySerializer, eg: []string -> [string, []*User -> [com.test.User.
+// The return value is "" if the elements have no java type.
+func getListType(typ reflect.Type) string {
+	elem := typ.Elem()
+	switch elem {
+	case _stringType:
+		return ARRAY_STRING
+	case _int32Type:
+		return ARRAY_INT
+	case _intType, _int64Type:
+		return ARRAY_LONG
+	case _float64Type:
+		return ARRAY_DOUBLE
+	case _float32Type:
+		return ARRAY_FLOAT
+	case _boolType:
+		return ARRAY_BOOL
+	}
+
+	elem = UnpackPtrType(elem)
+	if elem.Kind() == reflect.Interface {
+		return ""
+	}
+	if p, ok := reflect.New(elem).Interface().(POJO); ok {
+		return "[" + p.JavaClassName()
+	}
+	if elem.Kind() == reflect.Struct {
+		if javaName := getStructJavaName(elem); javaName != "" {
+			return "[" + javaName
+		}
+	}
+
+	return ""
+}
+
+// getListElemType returns the go element type of java list type @listType, which is the
+// reverse of getListType, eg: [int -> int32, [com.test.User -> *User if User is registered.
+// The return value is nil if the list should be decoded as []interface{}.
+func getListElemType(listType string) reflect.Type {
+	switch listType {
+	case ARRAY_STRING:
+		return _stringType
+	case ARRAY_INT:
+		return _int32Type
+	case ARRAY_LONG:
+		return _int64Type
+	case ARRAY_DOUBLE:
+		return _float64Type
+	case ARRAY_FLOAT:
+		return _float32Type
+	case ARRAY_BOOL:
+		return _boolType
+	}
+
+	if !strings.HasPrefix(listType, "[") {
+		return nil
+	}
+	s, ok := getStructInfo(listType[1:])
+	if !ok || s.typ.Kind() != reflect.Struct {
+		return nil
+	}
+	typ := reflect.PtrTo(s.typ)
+	// the decoded value is not *s.typ, eg: java.math.BigInteger is decoded as *big.Int
+	if typ.Implements(_resolverType) {
+		return nil
+	}
+
+	return typ
+}
+
+func (e *Encoder) encJavaSet(set JavaSet) error {
+	class := set.Class
+	if class == "" {
+		class = javaHashSet
+	}
+
+	value := UnpackPtrValue(reflect.ValueOf(set.Values))
+	if value.Kind() != reflect.Map {
+		return e.encTypedList(set.Values, class)
+	}
+	if value.IsNil() {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+
+	values := make([]interface{}, 0, value.Len())
+	for _, k := range value.MapKeys() {
+		values = append(values, k.Interface())
+	}
+
+	return e.encTypedList(values, class)
+}
+
 func (e *Encoder) encUntypedList(v interface{}) error {
 	var (
 		err error
 	)
 
-	value := reflect.ValueOf(v)
+	value := UnpackPtrValue(reflect.ValueOf(v))
 
 	// check ref
 	if n, ok := e.checkRefMap(value); ok {
@@ -47,8 +193,13 @@ func (e *Encoder) encUntypedList(v interface{}) error {
 		return nil
 	}
 
-	e.buffer = encByte(e.buffer, BC_LIST_FIXED_UNTYPED) // x58
-	e.buffer = encInt32(e.buffer, int32(value.Len()))
+	// just like java Hessian2Output.writeListBegin
+	if value.Len() <= int(_listFixedUntypedLenTagMax-_listFixedUntypedLenTagMin) {
+		e.buffer = encByte(e.buffer, BC_LIST_DIRECT_UNTYPED+byte(value.Len())) // [x78-7f]
+	} else {
+		e.buffer = encByte(e.buffer, BC_LIST_FIXED_UNTYPED) // x58
+		e.buffer = encInt32(e.buffer, int32(value.Len()))
+	}
 	for i := 0; i < value.Len(); i++ {
 		if err = e.Encode(value.Index(i).Interface()); err != nil {
 			return err
@@ -58,6 +209,49 @@ func (e *Encoder) encUntypedList(v interface{}) error {
 	return nil
 }
 
+// encTypedList encodes slice @v as a java list or array whose type is @listType,
+// just like java Hessian2Output.writeListBegin.
+// ::= 'V' type int value*   # fixed-length list
+// ::= [x70-77] type value*  # fixed-length typed list
+func (e *Encoder) encTypedList(v interface{}, listType string) error {
+	var (
+		err error
+	)
+
+	value := UnpackPtrValue(reflect.ValueOf(v))
+	if !value.IsValid() || (value.Kind() == reflect.Ptr && value.IsNil()) ||
+		(value.Kind() == reflect.Slice && value.IsNil()) {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+	if value.Kind() != reflect.Slice && value.Kind() != reflect.Array {
+		return jerrors.Errorf("%T is not a slice or array", v)
+	}
+
+	// check ref
+	if n, ok := e.checkRefMap(value); ok {
+		e.buffer = encRef(e.buffer, n)
+		return nil
+	}
+
+	n := value.Len()
+	if n <= int(_listFixedTypedLenTagMax-_listFixedTypedLenTagMin) {
+		e.buffer = encByte(e.buffer, BC_LIST_DIRECT+byte(n))
+		e.encType(listType)
+	} else {
+		e.buffer = encByte(e.buffer, BC_LIST_FIXED)
+		e.encType(listType)
+		e.buffer = encInt32(e.buffer, int32(n))
+	}
+	for i := 0; i < n; i++ {
+		if err = e.Encode(value.Index(i).Interface()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 /////////////////////////////////////////
 // List
 /////////////////////////////////////////
@@ -144,9 +338,9 @@ func (d *Decoder) decList(flag int32) (interface{}, error) {
 //      ::= 'V' type int value*   # fixed-length list
 //      ::= [x70-77] type value*  # fixed-length typed list
 func (d *Decoder) readTypedList(tag byte) (interface{}, error) {
-	listTyp, err := d.decString(TAG_READ)
+	listTyp, err := d.decType()
 	if err != nil {
-		return nil, jerrors.Errorf("error to read list type[%s]: %v", listTyp, err)
+		return nil, jerrors.Annotatef(err, "error to read list type[%s]", listTyp)
 	}
 
 	isVariableArr := tag == BC_LIST_VARIABLE
@@ -171,30 +365,47 @@ func (d *Decoder) readTypedList(tag byte) (interface{}, error) {
 		return nil, nil
 	}
 
-	arr := make([]interface{}, length)
-	aryValue := reflect.ValueOf(arr)
+	elemType := getListElemType(listTyp)
+	if elemType == nil {
+		elemType = _interfaceType
+	}
+	aryValue := reflect.MakeSlice(reflect.SliceOf(elemType), length, length)
+	refIndex := len(d.refs)
 	holder := d.appendRefs(aryValue)
 
 	for j := 0; j < length || isVariableArr; j++ {
-		it, err := d.Decode()
-		if err != nil {
-			if err == io.EOF && isVariableArr {
+		if isVariableArr {
+			end, err := d.readEnd()
+			if err != nil {
+				return nil, err
+			}
+			if end {
 				break
 			}
-			return nil, jerrors.Trace(err)
 		}
 
-		if it == nil {
-			break
+		it, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
 		}
 
 		v := EnsureRawValue(it)
 		if isVariableArr {
-			aryValue = reflect.Append(aryValue, v)
+			aryValue = reflect.Append(aryValue, reflect.Zero(elemType))
 			holder.change(aryValue)
-		} else {
-			SetValue(aryValue.Index(j), v)
 		}
+		SetValue(aryValue.Index(j), v)
+	}
+
+	// keep the java type of collections and arrays for encoding them again,
+	// the refs to the list get the java type too
+	if elemType == _interfaceType && listTyp != javaArrayList {
+		var c interface{} = JavaList{Class: listTyp, List: holder.value.Interface()}
+		if javaSetClasses[listTyp] {
+			c = JavaSet{Class: listTyp, Values: holder.value.Interface()}
+		}
+		d.refs[refIndex] = c
+		return c, nil
 	}
 
 	return holder, nil
@@ -228,12 +439,19 @@ func (d *Decoder) readUntypedList(tag byte) (interface{}, error) {
 	holder := d.appendRefs(aryValue)
 
 	for j := 0; j < length || isVariableArr; j++ {
+		if isVariableArr {
+			end, err := d.readEnd()
+			if err != nil {
+				return nil, err
+			}
+			if end {
+				break
+			}
+		}
+
 		it, err := d.Decode()
 		if err != nil {
-			if err == io.EOF && isVariableArr {
-				continue
-			}
-			return nil, jerrors.Trace(err)
+			return nil, jerrors.Trace(unexpectedEOF(err))
 		}
 
 		if isVariableArr {
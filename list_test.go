@@ -15,6 +15,9 @@
 package hessian
 
 import (
+	"bytes"
+	"reflect"
+	"strconv"
 	"testing"
 )
 
@@ -41,3 +44,305 @@ func TestEncList(t *testing.T) {
 	}
 	t.Logf("decode(%v) = %v, %v\n", list, res, err)
 }
+
+func TestGetListType(t *testing.T) {
+	tests := []struct {
+		list     interface{}
+		listType string
+	}{
+		{[]string{}, "[string"},
+		{[]int32{}, "[int"},
+		{[]int64{}, "[long"},
+		{[]int{}, "[long"},
+		{[]float64{}, "[double"},
+		{[]float32{}, "[float"},
+		{[]bool{}, "[boolean"},
+		{[2]string{}, "[string"},
+		{&[]string{}, "[string"},
+		{[]*valueUser{}, "[com.bdt.info.ValueUser"},
+		{[]valueUser{}, "[com.bdt.info.ValueUser"},
+		{[]interface{}{}, ""},
+		{[]uint8{}, ""},
+		{[][]string{}, ""},
+		{[]map[string]int{}, ""},
+	}
+
+	for _, test := range tests {
+		if listType := getListType(UnpackPtrType(reflect.TypeOf(test.list))); listType != test.listType {
+			t.Errorf("getListType(%T) = %q, want %q", test.list, listType, test.listType)
+		}
+	}
+}
+
+func TestEncTypedList(t *testing.T) {
+	e := NewEncoder()
+	if err := e.Encode([]string{"a", "b"}); err != nil {
+		t.Fatalf("Encode() = error:%v", err)
+	}
+	want := append([]byte{0x72, 0x07}, "[string"...)
+	want = append(want, 0x01, 'a', 0x01, 'b')
+	assertEqual(want, e.Buffer(), t)
+
+	// the second list type is encoded as a type ref
+	e = NewEncoder()
+	if err := e.Encode([]interface{}{[]string{"a"}, []string{"b"}}); err != nil {
+		t.Fatalf("Encode() = error:%v", err)
+	}
+	want = append([]byte{0x7a, 0x71, 0x07}, "[string"...)
+	want = append(want, 0x01, 'a', 0x71, 0x90, 0x01, 'b')
+	assertEqual(want, e.Buffer(), t)
+
+	var ss [][]string
+	if err := NewDecoder(e.Buffer()).DecodeValue(&ss); err != nil {
+		t.Fatalf("DecodeValue() = error:%v", err)
+	}
+	if !reflect.DeepEqual(ss, [][]string{{"a"}, {"b"}}) {
+		t.Errorf("DecodeValue() = %v", ss)
+	}
+
+	// fixed-length list
+	e = NewEncoder()
+	if err := e.Encode(make([]int32, 8)); err != nil {
+		t.Fatalf("Encode() = error:%v", err)
+	}
+	want = append([]byte{'V', 0x04}, "[int"...)
+	want = append(want, 0x98)
+	for i := 0; i < 8; i++ {
+		want = append(want, 0x90)
+	}
+	assertEqual(want, e.Buffer(), t)
+}
+
+func TestDecTypedList(t *testing.T) {
+	u := &valueUser{Name: "dubbo", Age: 18}
+	for _, list := range []interface{}{
+		[]string{"a", "b"},
+		[]int32{1, 2},
+		[]int64{1, 2},
+		[]float64{1.5},
+		[]bool{true, false},
+		[]*valueUser{u, nil, u},
+		make([]string, 8),
+	} {
+		e := NewEncoder()
+		if err := e.Encode(list); err != nil {
+			t.Fatalf("Encode(%v) = error:%v", list, err)
+		}
+		res, err := EnsureInterface(NewDecoder(e.Buffer()).Decode())
+		if err != nil {
+			t.Fatalf("Decode(%v) = error:%v", list, err)
+		}
+		if !reflect.DeepEqual(res, list) {
+			t.Errorf("Decode() = %#v, want %#v", res, list)
+		}
+	}
+
+	// java.util.ArrayList is a generic list
+	b := append([]byte{0x71, 0x13}, "java.util.ArrayList"...)
+	b = append(b, 0x01, 'a')
+	res, err := EnsureInterface(NewDecoder(b).Decode())
+	if err != nil {
+		t.Fatalf("Decode() = error:%v", err)
+	}
+	if !reflect.DeepEqual(res, []interface{}{"a"}) {
+		t.Errorf("Decode() = %#v, want []interface{}{\"a\"}", res)
+	}
+}
+
+func TestListArg(t *testing.T) {
+	for _, n := range []int{0, 1, 7, 8} {
+		typed := make([]string, n)
+		untyped := make([]interface{}, n)
+		for i := range typed {
+			typed[i] = strconv.Itoa(i + 1)
+			untyped[i] = typed[i]
+		}
+		testJavaDecode(t, "argTypedFixedList_"+strconv.Itoa(n), typed)
+		testJavaDecode(t, "argUntypedFixedList_"+strconv.Itoa(n), untyped)
+
+		// byte for byte the same as java
+		for method, list := range map[string]interface{}{
+			"replyTypedFixedList_" + strconv.Itoa(n):   typed,
+			"replyUntypedFixedList_" + strconv.Itoa(n): untyped,
+		} {
+			e := NewEncoder()
+			if err := e.Encode(list); err != nil {
+				t.Errorf("%s: encode fail with error %v", method, err)
+				continue
+			}
+			assertEqual(getReply(method), e.Buffer(), t)
+		}
+
+		res, err := EnsureInterface(decodeResponse("replyTypedFixedList_" + strconv.Itoa(n)))
+		if err != nil {
+			t.Errorf("replyTypedFixedList_%d: decode fail with error %v", n, err)
+		} else if !reflect.DeepEqual(res, typed) {
+			t.Errorf("replyTypedFixedList_%d: got %#v, want %#v", n, res, typed)
+		}
+	}
+}
+
+type CollectionHolder struct {
+	Lists map[string][]interface{}
+}
+
+func (CollectionHolder) JavaClassName() string {
+	return "test.CollectionHolder"
+}
+
+func TestJavaCollectionInMap(t *testing.T) {
+	in := map[string]interface{}{
+		"list": JavaList{Class: "java.util.LinkedList", List: []interface{}{"a"}},
+		"set":  JavaSet{Values: []interface{}{"b"}},
+	}
+	obj := &JavaObject{ClassName: CollectionHolder{}.JavaClassName(), Fields: []string{"lists"}, Values: []interface{}{in}}
+	RegisterPOJO(&CollectionHolder{})
+
+	e := NewEncoder()
+	if err := e.Encode(obj); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", obj, err)
+	}
+	res, err := EnsureInterface(NewDecoder(e.Buffer()).Decode())
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+	expected := &CollectionHolder{Lists: map[string][]interface{}{"list": {"a"}, "set": {"b"}}}
+	if !reflect.DeepEqual(expected, res) {
+		t.Errorf("Decode() = %#v, want %#v", res, expected)
+	}
+}
+
+func TestJavaCollection(t *testing.T) {
+	tests := []struct {
+		in      interface{}
+		encoded []byte
+		decoded interface{}
+	}{
+		{
+			JavaSet{Values: []string{"a"}},
+			append(append([]byte{0x71, 0x11}, "java.util.HashSet"...), 0x01, 'a'),
+			JavaSet{Class: "java.util.HashSet", Values: []interface{}{"a"}},
+		},
+		{
+			&JavaSet{Class: "java.util.TreeSet", Values: map[int32]bool{1: true}},
+			append(append([]byte{0x71, 0x11}, "java.util.TreeSet"...), 0x91),
+			JavaSet{Class: "java.util.TreeSet", Values: []interface{}{int32(1)}},
+		},
+		{
+			JavaList{Class: "java.util.LinkedList", List: []int32{1, 2}},
+			append(append([]byte{0x72, 0x14}, "java.util.LinkedList"...), 0x91, 0x92),
+			JavaList{Class: "java.util.LinkedList", List: []interface{}{int32(1), int32(2)}},
+		},
+		{
+			JavaList{Class: ARRAY_OBJECT, List: []interface{}{"a", int32(1)}},
+			append(append([]byte{0x72, 0x07}, "[object"...), 0x01, 'a', 0x91),
+			JavaList{Class: ARRAY_OBJECT, List: []interface{}{"a", int32(1)}},
+		},
+		{JavaSet{}, []byte{'N'}, nil},
+		{&JavaList{Class: "java.util.LinkedList"}, []byte{'N'}, nil},
+	}
+
+	for _, test := range tests {
+		e := NewEncoder()
+		if err := e.Encode(test.in); err != nil {
+			t.Fatalf("Encode(%#v) = error:%v", test.in, err)
+		}
+		assertEqual(test.encoded, e.Buffer(), t)
+
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("Decode(%#v) = error:%v", test.in, err)
+		}
+		if !reflect.DeepEqual(res, test.decoded) {
+			t.Errorf("Decode() = %#v, want %#v", res, test.decoded)
+		}
+
+		// encode the decoded value again
+		e = NewEncoder()
+		if err = e.Encode(res); err != nil {
+			t.Fatalf("Encode(%#v) = error:%v", res, err)
+		}
+		assertEqual(test.encoded, e.Buffer(), t)
+	}
+
+	// decode the values of java collection into go slice
+	e := NewEncoder()
+	e.Encode(JavaSet{Values: []string{"a", "b"}})
+	var ss []string
+	if err := NewDecoder(e.Buffer()).DecodeValue(&ss); err != nil {
+		t.Fatalf("DecodeValue() = error:%v", err)
+	}
+	if !reflect.DeepEqual(ss, []string{"a", "b"}) {
+		t.Errorf("DecodeValue() = %v", ss)
+	}
+
+	err := NewEncoder().Encode(JavaList{Class: "java.util.LinkedList", List: "a"})
+	if err == nil {
+		t.Errorf("Encode(JavaList{List: string}) should fail")
+	}
+}
+
+func TestJavaCollectionRef(t *testing.T) {
+	m := JavaMap{Class: "java.util.LinkedHashMap", Map: map[interface{}]interface{}{"a": int32(1)}}
+	l := JavaList{Class: "java.util.LinkedList", List: []interface{}{"b"}}
+	s := JavaSet{Class: "java.util.TreeSet", Values: []interface{}{"c"}}
+	in := []interface{}{m, m, l, l, s, s}
+
+	e := NewEncoder()
+	if err := e.Encode(in); err != nil {
+		t.Fatalf("Encode(%#v) = error:%v", in, err)
+	}
+	encoded := e.Buffer()
+	if n := bytes.Count(encoded, []byte{BC_REF}); n != 3 {
+		t.Fatalf("Encode(%#v) writes %d refs, want 3", in, n)
+	}
+
+	res, err := EnsureInterface(NewDecoder(encoded).Decode())
+	if err != nil {
+		t.Fatalf("Decode() = error:%v", err)
+	}
+	decoded := res.([]interface{})
+	// the refs keep the java types
+	for i := 0; i < len(decoded); i += 2 {
+		if reflect.TypeOf(decoded[i+1]) != reflect.TypeOf(in[i]) || !reflect.DeepEqual(decoded[i], decoded[i+1]) {
+			t.Errorf("Decode() = %#v at index %d, want %#v", decoded[i+1], i+1, decoded[i])
+		}
+	}
+
+	e = NewEncoder()
+	if err = e.Encode(decoded); err != nil {
+		t.Fatalf("Encode(%#v) = error:%v", decoded, err)
+	}
+	assertEqual(encoded, e.Buffer(), t)
+}
+
+func TestJavaCollectionJava(t *testing.T) {
+	for method, expected := range map[string]interface{}{
+		"customReplyHashSet":     JavaSet{Class: "java.util.HashSet", Values: []interface{}{"a"}},
+		"customReplyTreeSet":     JavaSet{Class: "java.util.TreeSet", Values: []interface{}{"a", "b"}},
+		"customReplyLinkedList":  JavaList{Class: "java.util.LinkedList", List: []interface{}{"a", "b"}},
+		"customReplyObjectArray": JavaList{Class: ARRAY_OBJECT, List: []interface{}{"a", int32(1)}},
+	} {
+		res, err := decodeResponse(method)
+		if err != nil {
+			t.Errorf("%s: decode fail with error %v", method, err)
+			continue
+		}
+		if !reflect.DeepEqual(res, expected) {
+			t.Errorf("%s: got %#v, want %#v", method, res, expected)
+		}
+
+		// byte for byte the same as java
+		e := NewEncoder()
+		if err = e.Encode(res); err != nil {
+			t.Errorf("%s: encode fail with error %v", method, err)
+			continue
+		}
+		assertEqual(getReply(method), e.Buffer(), t)
+	}
+
+	testJavaDecode(t, "customArgHashSet", JavaSet{Values: map[string]struct{}{"a": {}, "b": {}}})
+	testJavaDecode(t, "customArgTreeSet", JavaSet{Class: "java.util.TreeSet", Values: []int32{2, 1}})
+	testJavaDecode(t, "customArgLinkedList", JavaList{Class: "java.util.LinkedList", List: []string{"a", "b"}})
+	testJavaDecode(t, "customArgObjectArray", JavaList{Class: ARRAY_OBJECT, List: []interface{}{"a", int32(1)}})
+}
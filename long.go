@@ -69,7 +69,10 @@ func (d *Decoder) decInt64(flag int32) (int64, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return 0, jerrors.Trace(err)
+		}
 	}
 
 	switch {
@@ -84,25 +87,25 @@ func (d *Decoder) decInt64(flag int32) (int64, error) {
 
 		// direct integer
 	case tag >= 0x80 && tag <= 0xbf:
-		return int64(tag - BC_INT_ZERO), nil
+		return int64(int8(tag - BC_INT_ZERO)), nil
 
 		// byte int
 	case tag >= 0xc0 && tag <= 0xcf:
 		if _, err = io.ReadFull(d.reader, buf[:1]); err != nil {
 			return 0, jerrors.Trace(err)
 		}
-		return int64(tag-BC_INT_BYTE_ZERO)<<8 + int64(buf[0]), nil
+		return int64(int8(tag-BC_INT_BYTE_ZERO))<<8 + int64(buf[0]), nil
 
 		// short int
 	case tag >= 0xd0 && tag <= 0xd7:
 		if _, err = io.ReadFull(d.reader, buf[:2]); err != nil {
 			return 0, jerrors.Trace(err)
 		}
-		return int64(tag-BC_INT_SHORT_ZERO)<<16 + int64(buf[0])<<8 + int64(buf[1]), nil
+		return int64(int8(tag-BC_INT_SHORT_ZERO))<<16 + int64(buf[0])<<8 + int64(buf[1]), nil
 
 	case tag == BC_DOUBLE_BYTE:
-		tag, _ = d.readByte()
-		return int64(tag), nil
+		tag, err = d.readByte()
+		return int64(tag), jerrors.Trace(err)
 
 	case tag == BC_DOUBLE_SHORT:
 		if _, err = io.ReadFull(d.reader, buf[:2]); err != nil {
@@ -112,7 +115,7 @@ func (d *Decoder) decInt64(flag int32) (int64, error) {
 		return int64(int(buf[0])<<8 + int(buf[1])), nil
 
 	case tag == BC_INT: // 'I'
-		i32, err := d.decInt32(TAG_READ)
+		i32, err := d.decInt32(int32(tag))
 		return int64(i32), err
 
 	case tag == BC_LONG_INT:
@@ -15,6 +15,8 @@
 package hessian
 
 import (
+	"math"
+	"reflect"
 	"testing"
 )
 
@@ -160,3 +162,115 @@ func TestLong(t *testing.T) {
 	testLongFramework(t, "replyLong_m8", -8)
 	testLongFramework(t, "replyLong_m9", -9)
 }
+
+func TestEncUintKinds(t *testing.T) {
+	cases := []struct {
+		v        interface{}
+		expected int64
+	}{
+		{int(-1), -1},
+		{uint32(0xffffffff), 0xffffffff},
+		{uint(0x7fffffff), 0x7fffffff},
+		{uint64(math.MaxInt64), math.MaxInt64},
+	}
+
+	for _, c := range cases {
+		e := NewEncoder()
+		if err := e.Encode(c.v); err != nil {
+			t.Fatalf("encode(%T) = error:%v", c.v, err)
+		}
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("decode(%T) = error:%v", c.v, err)
+		}
+		if res != c.expected {
+			t.Errorf("decode(%T(%v)) = %T(%v)", c.v, c.v, res, res)
+		}
+	}
+
+	if err := NewEncoder().Encode(uint64(math.MaxInt64 + 1)); err == nil {
+		t.Errorf("encode(uint64(%d)) should overflow", uint64(math.MaxInt64+1))
+	}
+	if err := NewEncoder().Encode(uint(math.MaxUint64)); err == nil {
+		t.Errorf("encode(uint(%d)) should overflow", uint(math.MaxUint64))
+	}
+}
+
+func TestDecInt64FromInt(t *testing.T) {
+	for _, v := range []int32{-1, -16, 47, -0x800, 0x7ff, -0x40000, 0x3ffff, math.MinInt32, math.MaxInt32} {
+		e := NewEncoder()
+		e.Encode(v)
+		res, err := NewDecoder(e.Buffer()).decInt64(TAG_READ)
+		if err != nil || res != int64(v) {
+			t.Errorf("decInt64(int %d) = %d, error:%v", v, res, err)
+		}
+	}
+}
+
+type IntKinds struct {
+	I8  int8
+	I16 int16
+	I32 int32
+	I64 int64
+	I   int
+	U8  uint8
+	U16 uint16
+	U32 uint32
+	U64 uint64
+	U   uint
+}
+
+func (IntKinds) JavaClassName() string {
+	return "test.IntKinds"
+}
+
+func TestIntKindsPOJO(t *testing.T) {
+	in := &IntKinds{
+		I8: math.MinInt8, I16: math.MinInt16, I32: math.MinInt32, I64: math.MinInt64, I: -1,
+		U8: math.MaxUint8, U16: math.MaxUint16, U32: math.MaxUint32, U64: math.MaxInt64, U: 1,
+	}
+	e := NewEncoder()
+	if err := e.Encode(in); err != nil {
+		t.Fatalf("encode(%#v) = error:%v", in, err)
+	}
+	res, err := EnsureInterface(NewDecoder(e.Buffer()).Decode())
+	if err != nil {
+		t.Fatalf("decode(%#v) = error:%v", in, err)
+	}
+	if !reflect.DeepEqual(in, res) {
+		t.Errorf("decode(%#v) = %#v", in, res)
+	}
+
+	// the java field values which overflow the go field types
+	overflow := &JavaObject{
+		ClassName: in.JavaClassName(),
+		Fields:    []string{"i8", "u8"},
+		Values:    []interface{}{int32(math.MaxInt8 + 1), int32(0)},
+	}
+	for _, values := range [][]interface{}{{int32(math.MaxInt8 + 1), int32(0)}, {int32(0), int32(-1)}, {int32(0), int32(math.MaxUint8 + 1)}} {
+		overflow.Values = values
+		e = NewEncoder()
+		if err = e.Encode(overflow); err != nil {
+			t.Fatalf("encode(%#v) = error:%v", overflow, err)
+		}
+		if _, err = NewDecoder(e.Buffer()).Decode(); err == nil {
+			t.Errorf("decode(%v) should overflow", values)
+		}
+	}
+}
+
+func TestLongArg(t *testing.T) {
+	testJavaDecode(t, "argLong_0", uint64(0))
+	testJavaDecode(t, "argLong_1", uint(1))
+	testJavaDecode(t, "argLong_15", uint32(15))
+	testJavaDecode(t, "argLong_m8", int(-8))
+	testJavaDecode(t, "argLong_0x10", uint64(0x10))
+	testJavaDecode(t, "argLong_0x7ff", uint(0x7ff))
+	testJavaDecode(t, "argLong_0x800", uint32(0x800))
+	testJavaDecode(t, "argLong_m0x800", int(-0x800))
+	testJavaDecode(t, "argLong_0x3ffff", uint64(0x3ffff))
+	testJavaDecode(t, "argLong_0x40000", uint(0x40000))
+	testJavaDecode(t, "argLong_0x7fffffff", uint32(0x7fffffff))
+	testJavaDecode(t, "argLong_0x80000000", uint32(0x80000000))
+	testJavaDecode(t, "argLong_m0x80000001", int(-0x80000001))
+}
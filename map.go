@@ -15,14 +15,16 @@
 package hessian
 
 import (
-	"io"
 	"reflect"
+	"sync"
 )
 
 import (
 	jerrors "github.com/juju/errors"
 )
 
+var mapTypes sync.Map // java map class name --> go map type
+
 /////////////////////////////////////////
 // map/object
 /////////////////////////////////////////
@@ -56,6 +58,38 @@ func (e *Encoder) encUntypedMap(m map[interface{}]interface{}) error {
 	return nil
 }
 
+// encStructAsMap encodes go struct @v which has no java class name as an untyped map
+func (e *Encoder) encStructAsMap(v interface{}) error {
+	vv := reflect.ValueOf(v)
+	// check ref
+	if n, ok := e.checkRefMap(vv); ok {
+		e.buffer = encRef(e.buffer, n)
+		return nil
+	}
+
+	vv = UnpackPtr(vv)
+	// check nil pointer
+	if !vv.IsValid() {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+
+	e.buffer = encByte(e.buffer, BC_MAP_UNTYPED)
+	for _, f := range getFields(vv.Type()) {
+		field := fieldByIndex(vv, f.index, false)
+		if !field.IsValid() { // the field of a nil embedded struct pointer
+			continue
+		}
+		e.buffer = encString(e.buffer, f.name)
+		if err := e.Encode(field.Interface()); err != nil {
+			return jerrors.Annotatef(err, "failed to encode field: %s, %+v", f.name, field.Interface())
+		}
+	}
+	e.buffer = encByte(e.buffer, BC_END) // 'Z'
+
+	return nil
+}
+
 func getMapKey(key reflect.Value, t reflect.Type) (interface{}, error) {
 	switch t.Kind() {
 	case reflect.Bool:
@@ -93,12 +127,28 @@ func getMapKey(key reflect.Value, t reflect.Type) (interface{}, error) {
 
 	case reflect.String:
 		return key.String(), nil
+
+	case reflect.Interface:
+		return key.Interface(), nil
 	}
 
 	return nil, jerrors.Errorf("unsupported map key kind %s", t.Kind().String())
 }
 
-func (e *Encoder) encMap(m interface{}) error {
+// JavaMap is a go map with its java map class, which is encoded as a typed map, eg:
+// JavaMap{Class: "java.util.TreeMap", Map: map[string]int32{"a": 1}}.
+// The typed maps of java classes which have no registered go type are decoded as JavaMap
+// whose Map is a map[interface{}]interface{}, which is encoded in the decoded key order.
+type JavaMap struct {
+	Class string      // java map class name, eg: java.util.LinkedHashMap
+	Map   interface{} // go map or pointer to go map
+	keys  []interface{}
+}
+
+// encMap encodes go map @m as an untyped map if @javaClass is empty,
+// otherwise as a typed map of java class @javaClass.
+// The keys are encoded in the order of @order if it has all the keys of @m.
+func (e *Encoder) encMap(m interface{}, javaClass string, order []interface{}) error {
 	var (
 		err   error
 		k     interface{}
@@ -107,30 +157,43 @@ func (e *Encoder) encMap(m interface{}) error {
 		keys  []reflect.Value
 	)
 
-	value = reflect.ValueOf(m)
-
-	// check ref
-	if n, ok := e.checkRefMap(value); ok {
-		e.buffer = encRef(e.buffer, n)
-		return nil
-	}
-
-	value = UnpackPtrValue(value)
+	value = UnpackPtrValue(reflect.ValueOf(m))
 	// check nil map
-	if value.Kind() == reflect.Ptr && !value.Elem().IsValid() {
+	if !value.IsValid() || (value.Kind() == reflect.Ptr && !value.Elem().IsValid()) ||
+		(value.Kind() == reflect.Map && value.IsNil()) {
 		e.buffer = encNull(e.buffer)
 		return nil
 	}
+	if value.Kind() != reflect.Map {
+		return jerrors.Errorf("%T is not a map", m)
+	}
 
 	keys = value.MapKeys()
-	if len(keys) == 0 {
-		// fix: set nil for empty map
+	if len(keys) == 0 && javaClass == "" {
+		// fix: set nil for empty map.
+		// the null is not a ref object of the decoder, so the map is not added into the ref map.
 		e.buffer = encNull(e.buffer)
 		return nil
 	}
 
+	// check ref
+	if n, ok := e.checkRefMap(reflect.ValueOf(m)); ok {
+		e.buffer = encRef(e.buffer, n)
+		return nil
+	}
+
 	typ = value.Type().Key()
-	e.buffer = encByte(e.buffer, BC_MAP_UNTYPED)
+	if len(order) == len(keys) {
+		if ordered, ok := orderedMapKeys(value, order); ok {
+			keys = ordered
+		}
+	}
+	if javaClass == "" {
+		e.buffer = encByte(e.buffer, BC_MAP_UNTYPED)
+	} else {
+		e.buffer = encByte(e.buffer, BC_MAP)
+		e.encType(javaClass)
+	}
 	for i := 0; i < len(keys); i++ {
 		k, err = getMapKey(keys[i], typ)
 		if err != nil {
@@ -149,6 +212,24 @@ func (e *Encoder) encMap(m interface{}) error {
 	return nil
 }
 
+// orderedMapKeys returns the keys @order of map @m, the return value is false
+// if some key of @order is not in @m.
+func orderedMapKeys(m reflect.Value, order []interface{}) ([]reflect.Value, bool) {
+	keys := make([]reflect.Value, 0, len(order))
+	for _, k := range order {
+		key := reflect.ValueOf(k)
+		if !key.IsValid() {
+			key = reflect.Zero(m.Type().Key())
+		}
+		if !key.Type().AssignableTo(m.Type().Key()) || !m.MapIndex(key).IsValid() {
+			return nil, false
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, true
+}
+
 /////////////////////////////////////////
 // Map
 /////////////////////////////////////////
@@ -179,10 +260,13 @@ func (d *Decoder) decMapByValue(value reflect.Value) error {
 		if err != nil {
 			return jerrors.Trace(err)
 		}
-		SetValue(value, EnsurePackValue(refObj))
+		SetValue(value, EnsurePackValue(unpackJavaCollection(refObj)))
 		return nil
 	case BC_MAP:
-		d.decString(TAG_READ) // read map type , ignored
+		// read map type, ignored
+		if _, err = d.decType(); err != nil {
+			return jerrors.Trace(err)
+		}
 	case BC_MAP_UNTYPED:
 		//do nothing
 	default:
@@ -196,14 +280,16 @@ func (d *Decoder) decMapByValue(value reflect.Value) error {
 
 	//read key and value
 	for {
+		end, err := d.readEnd()
+		if err != nil {
+			return err
+		}
+		if end {
+			break
+		}
 		entryKey, err = d.Decode()
 		if err != nil {
-			// EOF means the end flag 'Z' of map is already read
-			if err == io.EOF {
-				break
-			} else {
-				return jerrors.Trace(err)
-			}
+			return jerrors.Trace(unexpectedEOF(err))
 		}
 		if entryKey == nil {
 			break
@@ -211,9 +297,11 @@ func (d *Decoder) decMapByValue(value reflect.Value) error {
 		entryValue, err = d.Decode()
 		// fix: check error
 		if err != nil {
-			return jerrors.Trace(err)
+			return jerrors.Trace(unexpectedEOF(err))
 		}
-		m.Elem().SetMapIndex(EnsurePackValue(entryKey), EnsurePackValue(entryValue))
+		// set the go value of java collection, eg: java.util.HashSet
+		m.Elem().SetMapIndex(EnsurePackValue(unpackJavaCollection(entryKey)),
+			EnsurePackValue(unpackJavaCollection(entryValue)))
 	}
 
 	SetValue(value, m)
@@ -223,26 +311,21 @@ func (d *Decoder) decMapByValue(value reflect.Value) error {
 
 func (d *Decoder) decMap(flag int32) (interface{}, error) {
 	var (
-		err        error
-		tag        byte
-		ok         bool
-		k          interface{}
-		v          interface{}
-		t          string
-		keyName    string
-		methodName string
-		key        interface{}
-		value      interface{}
-		inst       interface{}
-		m          map[interface{}]interface{}
-		fieldValue reflect.Value
-		args       []reflect.Value
+		err error
+		tag byte
+		t   string
+		k   interface{}
+		v   interface{}
+		m   map[interface{}]interface{}
 	)
 
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return nil, jerrors.Trace(err)
+		}
 	}
 
 	switch {
@@ -252,90 +335,192 @@ func (d *Decoder) decMap(flag int32) (interface{}, error) {
 		return d.decRef(int32(tag))
 	case tag == BC_MAP:
 		if t, err = d.decType(); err != nil {
-			return nil, err
+			return nil, jerrors.Trace(err)
 		}
-		if _, ok = checkPOJORegistry(t); ok {
-			m = make(map[interface{}]interface{}) // 此处假设了map的定义形式，这是不对的
-			d.appendRefs(m)
-
-			// d.decType() // 忽略
-			for d.peekByte() != byte('z') {
-				k, err = d.Decode()
-				if err != nil {
-					if err == io.EOF {
-						break
-					}
-
-					return nil, err
-				}
-				v, err = d.Decode()
-				if err != nil {
-					return nil, err
-				}
-				m[k] = v
-			}
-			_, err = d.readByte()
-			// check error
-			if err != nil {
-				return nil, jerrors.Trace(err)
-			}
-
-			return m, nil
-		} else {
-			inst = createInstance(t)
-			d.appendRefs(inst)
-
-			for d.peekByte() != 'z' {
-				if key, err = d.Decode(); err != nil {
-					return nil, err
-				}
-				if value, err = d.Decode(); err != nil {
-					return nil, err
-				}
-				//set value of the struct to Zero
-				if fieldValue = reflect.ValueOf(value); fieldValue.IsValid() {
-					keyName = key.(string)
-					if keyName[0] >= 'a' { //convert to Upper
-						methodName = "Set" + string(keyName[0]-32) + keyName[1:]
-					} else {
-						methodName = "Set" + keyName
-					}
-
-					args = args[:0]
-					args = append(args, fieldValue)
-					reflect.ValueOf(inst).MethodByName(methodName).Call(args)
-				}
-			}
-
-			return inst, nil
+		// hessian 1 style object
+		if s, ok := getStructInfo(t); ok && s.typ.Kind() == reflect.Struct {
+			return d.readObjectMap(s.typ, t)
 		}
+		if typ, ok := getMapType(t); ok {
+			return d.readTypedMap(typ)
+		}
+		return d.readJavaMap(t)
 
 	case tag == BC_MAP_UNTYPED:
 		m = make(map[interface{}]interface{})
 		d.appendRefs(m)
-		for d.peekByte() != byte(BC_END) {
-			k, err = d.Decode()
+		for {
+			end, err := d.readEnd()
 			if err != nil {
-				if err == io.EOF {
-					break
-				}
-
 				return nil, err
 			}
+			if end {
+				break
+			}
+			k, err = d.Decode()
+			if err != nil {
+				return nil, jerrors.Trace(unexpectedEOF(err))
+			}
 			v, err = d.Decode()
 			if err != nil {
-				return nil, err
+				return nil, jerrors.Trace(unexpectedEOF(err))
 			}
 			m[k] = v
 		}
-		_, err = d.readByte()
-		// check error
-		if err != nil {
-			return nil, jerrors.Trace(err)
-		}
 		return m, nil
 
 	default:
 		return nil, jerrors.Errorf("illegal map type tag:%+v", tag)
 	}
 }
+
+// readTypedMap reads the entries of a typed map into a new go map of type @typ.
+func (d *Decoder) readTypedMap(typ reflect.Type) (interface{}, error) {
+	m := reflect.MakeMap(typ)
+	d.appendRefs(m.Interface())
+	for {
+		end, err := d.readEnd()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			break
+		}
+		k, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
+		}
+		v, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
+		}
+
+		key, err := newMapEntry(typ.Key(), k)
+		if err != nil {
+			return nil, jerrors.Annotatef(err, "illegal map key %v", k)
+		}
+		value, err := newMapEntry(typ.Elem(), v)
+		if err != nil {
+			return nil, jerrors.Annotatef(err, "illegal map value %v", v)
+		}
+		m.SetMapIndex(key, value)
+	}
+
+	return m.Interface(), nil
+}
+
+// readJavaMap reads the entries of a typed map of java class @javaClass, which has
+// no registered go type, into a JavaMap.
+func (d *Decoder) readJavaMap(javaClass string) (interface{}, error) {
+	m := make(map[interface{}]interface{})
+	jm := JavaMap{Class: javaClass, Map: m}
+	refIndex := len(d.refs)
+	d.appendRefs(m)
+	for {
+		end, err := d.readEnd()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			break
+		}
+		k, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
+		}
+		v, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
+		}
+
+		key := hashableMapKey(k)
+		m[key] = unpackDecodedValue(v)
+		jm.keys = append(jm.keys, key)
+	}
+
+	// the refs to the map get the java type too
+	d.refs[refIndex] = jm
+	return jm, nil
+}
+
+// hashableMapKey unpacks the decoded value @in as a map key, the ref holder of a slice
+// is kept as it is, because a slice can not be a map key.
+func hashableMapKey(in interface{}) interface{} {
+	switch k := unpackDecodedValue(in).(type) {
+	case nil:
+		return nil
+	case JavaList:
+		return &k
+	case JavaSet:
+		return &k
+	case JavaMap:
+		return &k
+	default:
+		if reflect.TypeOf(k).Comparable() {
+			return k
+		}
+		return in
+	}
+}
+
+// newMapEntry converts the decoded value @in to a new value of type @typ.
+func newMapEntry(typ reflect.Type, in interface{}) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	if err := assignValue(v, in); err != nil {
+		return reflect.Value{}, jerrors.Trace(err)
+	}
+
+	return v, nil
+}
+
+// readObjectMap reads a hessian 1 style object, which is a typed map whose keys are
+// the field names of java class @javaName, into a new go struct of type @typ.
+func (d *Decoder) readObjectMap(typ reflect.Type, javaName string) (interface{}, error) {
+	vRef := reflect.New(typ)
+	d.appendRefs(vRef)
+	for {
+		end, err := d.readEnd()
+		if err != nil {
+			return nil, err
+		}
+		if end {
+			break
+		}
+		k, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
+		}
+		fieldName, ok := k.(string)
+		if !ok {
+			return nil, jerrors.Errorf("illegal field name %v of java class %s", k, javaName)
+		}
+		if err = d.decField(vRef.Elem(), javaName, fieldName); err != nil {
+			return nil, jerrors.Trace(unexpectedEOF(err))
+		}
+	}
+
+	return vRef, nil
+}
+
+// RegisterMapType registers go map type of @m as the decoded type of the typed map of
+// java class @javaClassName, eg: RegisterMapType("java.util.TreeMap", map[string]int64{}).
+// The typed maps of unregistered java classes are decoded as JavaMap, and the untyped
+// maps, eg: java.util.HashMap, are decoded as map[interface{}]interface{}.
+func RegisterMapType(javaClassName string, m interface{}) error {
+	typ := reflect.TypeOf(m)
+	if typ == nil || typ.Kind() != reflect.Map {
+		return jerrors.Errorf("%T is not a map", m)
+	}
+
+	mapTypes.Store(javaClassName, typ)
+	return nil
+}
+
+func getMapType(javaClassName string) (reflect.Type, bool) {
+	typ, ok := mapTypes.Load(javaClassName)
+	if !ok {
+		return nil, false
+	}
+
+	return typ.(reflect.Type), true
+}
@@ -15,9 +15,14 @@
 package hessian
 
 import (
+	"reflect"
 	"testing"
 )
 
+import (
+	"github.com/stretchr/testify/assert"
+)
+
 func TestEncUntypedMap(t *testing.T) {
 	var (
 		m   map[interface{}]interface{}
@@ -73,3 +78,146 @@ func TestEncTypedMap(t *testing.T) {
 	}
 	t.Logf("decode(%v) = %v, %v\n", m, res, err)
 }
+
+func TestEncJavaMap(t *testing.T) {
+	e := NewEncoder()
+	err := e.Encode([]interface{}{
+		JavaMap{Class: "java.util.TreeMap", Map: map[string]int32{"a": 1}},
+		&JavaMap{Class: "java.util.TreeMap", Map: map[string]int32{}},
+		JavaMap{Class: "java.util.TreeMap"},
+	})
+	if err != nil {
+		t.Fatalf("Encode() = error:%v", err)
+	}
+
+	want := append([]byte{0x7b, 'M', 0x11}, "java.util.TreeMap"...)
+	want = append(want, 0x01, 'a', 0x91, 'Z')
+	// the second map type is encoded as a type ref
+	want = append(want, 'M', 0x90, 'Z')
+	want = append(want, 'N')
+	assert.Equal(t, want, e.Buffer())
+
+	err = NewEncoder().Encode(JavaMap{Class: "java.util.TreeMap", Map: []string{"a"}})
+	assert.Error(t, err)
+}
+
+func TestJavaMapJava(t *testing.T) {
+	m := map[string]int32{"a": 1, "b": 2}
+	testJavaDecode(t, "customArgTreeMap", JavaMap{Class: "java.util.TreeMap", Map: m})
+	testJavaDecode(t, "customArgLinkedHashMap", JavaMap{Class: "java.util.LinkedHashMap", Map: m})
+	testJavaDecode(t, "customArgConcurrentHashMap", &JavaMap{Class: "java.util.concurrent.ConcurrentHashMap", Map: &m})
+	testJavaDecode(t, "customArgEmptyTreeMap", JavaMap{Class: "java.util.TreeMap", Map: map[interface{}]interface{}{}})
+}
+
+func TestDecTypedMap(t *testing.T) {
+	typedMap := func(typ string, kv ...interface{}) []byte {
+		e := NewEncoder()
+		e.buffer = encByte(e.buffer, BC_MAP)
+		e.encType(typ)
+		for _, v := range kv {
+			if err := e.Encode(v); err != nil {
+				t.Fatalf("Encode(%v) = error:%v", v, err)
+			}
+		}
+		return encByte(e.buffer, BC_END)
+	}
+
+	// unregistered java map class
+	b := typedMap("test.UnknownMap", "z", int32(1), "a", []interface{}{"b"})
+	res, err := NewDecoder(b).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = error:%v", err)
+	}
+	jm, ok := res.(JavaMap)
+	if !ok {
+		t.Fatalf("Decode() = %#v, want JavaMap", res)
+	}
+	assert.Equal(t, "test.UnknownMap", jm.Class)
+	assert.Equal(t, map[interface{}]interface{}{"z": int32(1), "a": []interface{}{"b"}}, jm.Map)
+	// encoded in the decoded key order
+	for i := 0; i < 10; i++ {
+		e := NewEncoder()
+		if err = e.Encode(jm); err != nil {
+			t.Fatalf("Encode(%#v) = error:%v", jm, err)
+		}
+		assertEqual(b, e.Buffer(), t)
+	}
+	var sm map[string]interface{}
+	assert.NoError(t, NewDecoder(b).DecodeValue(&sm))
+	assert.Equal(t, map[string]interface{}{"z": int32(1), "a": []interface{}{"b"}}, sm)
+
+	// registered java map class
+	assert.Error(t, RegisterMapType("test.TypedMap", []string{}))
+	assert.NoError(t, RegisterMapType("test.TypedMap", map[int64][]string{}))
+	res, err = NewDecoder(typedMap("test.TypedMap", int32(1), []interface{}{"a"}, int64(2), nil)).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = error:%v", err)
+	}
+	assert.Equal(t, map[int64][]string{1: {"a"}, 2: nil}, res)
+
+	_, err = NewDecoder(typedMap("test.TypedMap", "a", int32(1))).Decode()
+	assert.Error(t, err)
+	_, err = NewDecoder(typedMap("test.TypedMap", int32(1), "a")).Decode()
+	assert.Error(t, err)
+	assert.NoError(t, RegisterMapType("test.Int8Map", map[int8]string{}))
+	_, err = NewDecoder(typedMap("test.Int8Map", int32(300), "a")).Decode()
+	assert.Error(t, err)
+
+	// hessian 1 style object
+	RegisterPOJO(&valueUser{})
+	res, err = NewDecoder(typedMap("com.bdt.info.ValueUser", "name", "dubbo", "age", int32(18),
+		"friends", []*valueUser{{Name: "go"}}, "unknown", "z")).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = error:%v", err)
+	}
+	expected := &valueUser{Name: "dubbo", Age: 18, Friends: []*valueUser{{Name: "go"}}}
+	if !reflect.DeepEqual(EnsureRawValue(res).Interface(), expected) {
+		t.Errorf("Decode() = %+v, want %+v", res, expected)
+	}
+}
+
+func TestTypedMapJava(t *testing.T) {
+	for method, expected := range map[string]interface{}{
+		"replyTypedMap_0": map[interface{}]interface{}{},
+		"replyTypedMap_1": map[interface{}]interface{}{"a": int32(0)},
+		"replyTypedMap_2": map[interface{}]interface{}{int32(0): "a", int32(1): "b"},
+	} {
+		res, err := decodeResponse(method)
+		if err != nil {
+			t.Errorf("%s: decode fail with error %v", method, err)
+			continue
+		}
+		jm, ok := res.(JavaMap)
+		if !ok {
+			t.Errorf("%s: got %#v, want JavaMap", method, res)
+			continue
+		}
+		assert.Equal(t, "java.util.Hashtable", jm.Class, method)
+		assert.Equal(t, expected, jm.Map, method)
+
+		// byte for byte the same as java
+		e := NewEncoder()
+		if err = e.Encode(jm); err != nil {
+			t.Errorf("%s: encode fail with error %v", method, err)
+			continue
+		}
+		assertEqual(getReply(method), e.Buffer(), t)
+	}
+
+	// byte for byte the same as java
+	for method, m := range map[string]interface{}{
+		"replyTypedMap_0": map[string]int32{},
+		"replyTypedMap_1": map[string]int32{"a": 0},
+	} {
+		e := NewEncoder()
+		if err := e.Encode(JavaMap{Class: "java.util.Hashtable", Map: m}); err != nil {
+			t.Errorf("%s: encode fail with error %v", method, err)
+			continue
+		}
+		assertEqual(getReply(method), e.Buffer(), t)
+	}
+
+	testJavaDecode(t, "argTypedMap_0", JavaMap{Class: "java.util.Hashtable", Map: map[string]int32{}})
+	testJavaDecode(t, "argTypedMap_1", JavaMap{Class: "java.util.Hashtable", Map: map[string]int32{"a": 0}})
+	testJavaDecode(t, "argTypedMap_2", JavaMap{Class: "java.util.Hashtable", Map: map[int32]string{0: "a", 1: "b"}})
+}
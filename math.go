@@ -0,0 +1,215 @@
+// Copyright (c) 2016 ~ 2019, Alex Stocks.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hessian
+
+import (
+	"encoding/binary"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+import (
+	jerrors "github.com/juju/errors"
+)
+
+func init() {
+	RegisterPOJO(&BigDecimal{})
+	RegisterPOJO(&bigInteger{})
+}
+
+/////////////////////////////////////////
+// java.math.BigDecimal
+/////////////////////////////////////////
+
+// BigDecimal is java.math.BigDecimal. Java hessian writes it as an object
+// whose only field "value" is the result of BigDecimal.toString().
+type BigDecimal struct {
+	Value string `hessian:"value"`
+}
+
+func (BigDecimal) JavaClassName() string {
+	return "java.math.BigDecimal"
+}
+
+func (d BigDecimal) String() string {
+	return d.Value
+}
+
+// NewBigDecimal creates a BigDecimal whose value is @unscaled * 10^-@scale,
+// its string is the same as java BigDecimal.toString().
+func NewBigDecimal(unscaled *big.Int, scale int32) BigDecimal {
+	var (
+		buf      strings.Builder
+		coeff    = new(big.Int).Abs(unscaled).String()
+		adjusted = -int64(scale) + int64(len(coeff)-1)
+	)
+
+	if unscaled.Sign() < 0 {
+		buf.WriteByte('-')
+	}
+
+	// java.math.BigDecimal.layoutChars
+	switch {
+	case scale == 0:
+		buf.WriteString(coeff)
+
+	case scale > 0 && adjusted >= -6: // plain notation
+		if pad := int(scale) - len(coeff); pad >= 0 {
+			buf.WriteString("0.")
+			buf.WriteString(strings.Repeat("0", pad))
+			buf.WriteString(coeff)
+		} else {
+			buf.WriteString(coeff[:-pad])
+			buf.WriteByte('.')
+			buf.WriteString(coeff[-pad:])
+		}
+
+	default: // scientific notation
+		buf.WriteString(coeff[:1])
+		if len(coeff) > 1 {
+			buf.WriteByte('.')
+			buf.WriteString(coeff[1:])
+		}
+		buf.WriteByte('E')
+		if adjusted > 0 {
+			buf.WriteByte('+')
+		}
+		buf.WriteString(strconv.FormatInt(adjusted, 10))
+	}
+
+	return BigDecimal{Value: buf.String()}
+}
+
+// NewBigDecimalFromFloat creates a BigDecimal from the shortest decimal
+// which can be converted back to @f exactly.
+func NewBigDecimalFromFloat(f *big.Float) (BigDecimal, error) {
+	if f.IsInf() {
+		return BigDecimal{}, jerrors.Errorf("can not convert %v to BigDecimal", f)
+	}
+
+	unscaled, scale, err := BigDecimal{Value: f.Text('e', -1)}.Unscaled()
+	if err != nil {
+		return BigDecimal{}, jerrors.Trace(err)
+	}
+
+	return NewBigDecimal(unscaled, scale), nil
+}
+
+// Unscaled returns the unscaled value and the scale of @d, just like
+// java BigDecimal.unscaledValue() and BigDecimal.scale().
+func (d BigDecimal) Unscaled() (*big.Int, int32, error) {
+	var (
+		exp   int64
+		err   error
+		scale int64
+		s     = d.Value
+	)
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		if exp, err = strconv.ParseInt(s[i+1:], 10, 32); err != nil {
+			return nil, 0, jerrors.Annotatef(err, "illegal BigDecimal %q", d.Value)
+		}
+		s = s[:i]
+	}
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		scale = int64(len(s) - i - 1)
+		s = s[:i] + s[i+1:]
+	}
+	scale -= exp
+	if scale != int64(int32(scale)) {
+		return nil, 0, jerrors.Errorf("the scale of BigDecimal %q overflows", d.Value)
+	}
+
+	unscaled, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		return nil, 0, jerrors.Errorf("illegal BigDecimal %q", d.Value)
+	}
+
+	return unscaled, int32(scale), nil
+}
+
+// Float returns the value of @d as a big.Float, whose precision is high
+// enough to keep all the digits of @d.
+func (d BigDecimal) Float() (*big.Float, error) {
+	// log2(10) < 4
+	prec := uint(len(d.Value))*4 + 64
+	f, ok := new(big.Float).SetPrec(prec).SetString(d.Value)
+	if !ok {
+		return nil, jerrors.Errorf("illegal BigDecimal %q", d.Value)
+	}
+
+	return f, nil
+}
+
+/////////////////////////////////////////
+// java.math.BigInteger
+/////////////////////////////////////////
+
+// bigInteger is the wire layout of java.math.BigInteger written by java hessian
+// JavaSerializer, which puts the primitive fields before the others. The deprecated
+// cache fields are always 0, which means "not computed yet" to java.
+// *big.Int is encoded as bigInteger, and bigInteger is decoded as *big.Int.
+type bigInteger struct {
+	Signum             int32   `hessian:"signum"`
+	BitCount           int32   `hessian:"bitCount"`
+	BitLength          int32   `hessian:"bitLength"`
+	LowestSetBit       int32   `hessian:"lowestSetBit"`
+	FirstNonzeroIntNum int32   `hessian:"firstNonzeroIntNum"`
+	Mag                []int32 `hessian:"mag"` // big-endian magnitude
+}
+
+func (bigInteger) JavaClassName() string {
+	return "java.math.BigInteger"
+}
+
+func newBigInteger(v *big.Int) *bigInteger {
+	b := new(big.Int).Abs(v).Bytes()
+	// pad to the multiple of 4 bytes
+	if pad := (4 - len(b)%4) % 4; pad > 0 {
+		b = append(make([]byte, pad), b...)
+	}
+
+	mag := make([]int32, len(b)/4)
+	for i := range mag {
+		mag[i] = int32(binary.BigEndian.Uint32(b[i*4:]))
+	}
+
+	return &bigInteger{Signum: int32(v.Sign()), Mag: mag}
+}
+
+// resolve returns the *big.Int value of @b
+func (b *bigInteger) resolve() interface{} {
+	buf := make([]byte, len(b.Mag)*4)
+	for i, m := range b.Mag {
+		binary.BigEndian.PutUint32(buf[i*4:], uint32(m))
+	}
+
+	v := new(big.Int).SetBytes(buf)
+	if b.Signum < 0 {
+		v.Neg(v)
+	}
+
+	return v
+}
+
+func (e *Encoder) encBigInt(v *big.Int) error {
+	if v == nil {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+
+	return e.encObject(newBigInteger(v), bigInteger{}.JavaClassName())
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2016 ~ 2019, Alex Stocks.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hessian
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+import (
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewBigDecimal(t *testing.T) {
+	cases := []struct {
+		unscaled string
+		scale    int32
+		expected string
+	}{
+		{"123", 0, "123"},
+		{"-123", 0, "-123"},
+		{"123", -1, "1.23E+3"},
+		{"123", 3, "0.123"},
+		{"-123", 2, "-1.23"},
+		{"123", 10, "1.23E-8"},
+		{"123", 8, "0.00000123"},
+		{"1", 7, "1E-7"},
+		{"0", 2, "0.00"},
+		{"123456789012345678901234567890", 20, "1234567890.12345678901234567890"},
+	}
+
+	for _, c := range cases {
+		unscaled, _ := new(big.Int).SetString(c.unscaled, 10)
+		d := NewBigDecimal(unscaled, c.scale)
+		assert.Equal(t, c.expected, d.String())
+
+		u, scale, err := d.Unscaled()
+		assert.Nil(t, err)
+		assert.Equal(t, unscaled, u)
+		assert.Equal(t, c.scale, scale)
+	}
+
+	_, _, err := BigDecimal{Value: "1.2.3"}.Unscaled()
+	assert.NotNil(t, err)
+}
+
+func TestBigDecimalFloat(t *testing.T) {
+	d := BigDecimal{Value: "123456789012345678901234567890.0123456789"}
+	f, err := d.Float()
+	assert.Nil(t, err)
+
+	d2, err := NewBigDecimalFromFloat(f)
+	assert.Nil(t, err)
+	assert.Equal(t, "123456789012345678901234567890.0123456789", d2.Value)
+
+	d2, err = NewBigDecimalFromFloat(big.NewFloat(0.1))
+	assert.Nil(t, err)
+	assert.Equal(t, "0.1", d2.Value)
+
+	_, err = NewBigDecimalFromFloat(new(big.Float).SetInf(false))
+	assert.NotNil(t, err)
+}
+
+func TestEncBigDecimal(t *testing.T) {
+	d := BigDecimal{Value: "100.256"}
+
+	e := NewEncoder()
+	err := e.Encode([]interface{}{d, &d})
+	assert.Nil(t, err)
+
+	var out []BigDecimal
+	err = NewDecoder(e.Buffer()).DecodeValue(&out)
+	assert.Nil(t, err)
+	assert.Equal(t, []BigDecimal{d, d}, out)
+}
+
+func TestBigDecimalJava(t *testing.T) {
+	for method, expected := range map[string]string{
+		"customReplyBigDecimal":    "100.256",
+		"customReplyBigDecimalExp": "-1.23E-10",
+	} {
+		r, err := decodeResponse(method)
+		if err != nil {
+			t.Errorf("%s: decode fail with error %v", method, err)
+			continue
+		}
+		d := r.(reflect.Value).Interface().(*BigDecimal)
+		assert.Equal(t, expected, d.Value)
+	}
+
+	testJavaDecode(t, "customArgBigDecimal", BigDecimal{Value: "100.256"})
+	unscaled := big.NewInt(-123)
+	testJavaDecode(t, "customArgBigDecimalExp", NewBigDecimal(unscaled, 12))
+}
+
+type Account struct {
+	ID      *big.Int
+	Balance BigDecimal
+	Nonce   big.Int
+}
+
+func (Account) JavaClassName() string {
+	return "com.bdt.info.Account"
+}
+
+func TestEncBigInteger(t *testing.T) {
+	values := []*big.Int{
+		big.NewInt(0),
+		big.NewInt(-1),
+		big.NewInt(0x7fffffff),
+		big.NewInt(-0x80000000),
+	}
+	for _, s := range []string{"4294967296000", "-123456789012345678901234567890123456789"} {
+		v, _ := new(big.Int).SetString(s, 10)
+		values = append(values, v)
+	}
+
+	for _, v := range values {
+		e := NewEncoder()
+		err := e.Encode(v)
+		assert.Nil(t, err)
+		res, err := NewDecoder(e.Buffer()).Decode()
+		assert.Nil(t, err)
+		assert.Equal(t, 0, v.Cmp(res.(*big.Int)), "%v != %v", v, res)
+	}
+
+	// struct field, list and map
+	acc := &Account{ID: values[4], Balance: BigDecimal{Value: "1.5"}}
+	acc.Nonce.SetInt64(7)
+	in := map[string]interface{}{
+		"account": acc,
+		"ids":     []interface{}{values[5], values[5]},
+	}
+
+	e := NewEncoder()
+	err := e.Encode(in)
+	assert.Nil(t, err)
+	res, err := NewDecoder(e.Buffer()).Decode()
+	assert.Nil(t, err)
+
+	m := res.(map[interface{}]interface{})
+	acc2 := m["account"].(reflect.Value).Interface().(*Account)
+	assert.Equal(t, 0, acc.ID.Cmp(acc2.ID))
+	assert.Equal(t, 0, acc.Nonce.Cmp(&acc2.Nonce))
+	assert.Equal(t, acc.Balance, acc2.Balance)
+
+	ids := m["ids"].(*_refHolder).value.Interface().([]interface{})
+	assert.Equal(t, 0, values[5].Cmp(ids[0].(*big.Int)))
+	assert.Equal(t, 0, values[5].Cmp(ids[1].(*big.Int)))
+}
+
+func TestBigIntegerJava(t *testing.T) {
+	expected, _ := new(big.Int).SetString("-123456789012345678901234567890123456789", 10)
+
+	r, err := decodeResponse("customReplyBigInteger")
+	if err != nil {
+		t.Fatalf("decode fail with error %v", err)
+	}
+	assert.Equal(t, 0, expected.Cmp(r.(*big.Int)))
+
+	testJavaDecode(t, "customArgBigInteger", expected)
+	testJavaDecode(t, "customArgBigIntegerZero", big.NewInt(0))
+}
@@ -17,18 +17,12 @@ package hessian
 import (
 	"io"
 	"reflect"
-	"strings"
 )
 
 import (
 	jerrors "github.com/juju/errors"
 )
 
-// get @v go struct name
-func typeof(v interface{}) string {
-	return reflect.TypeOf(v).String()
-}
-
 /////////////////////////////////////////
 // map/object
 /////////////////////////////////////////
@@ -93,9 +87,10 @@ func typeof(v interface{}) string {
 //  x04 BLUE                # BLUE value
 //
 //x51 x91                   # object ref #1, i.e. Color.GREEN
-func (e *Encoder) encObject(v POJO) error {
+func (e *Encoder) encObject(v interface{}, javaName string) error {
 	var (
 		ok     bool
+		isEnum bool
 		i      int
 		idx    int
 		num    int
@@ -117,24 +112,32 @@ func (e *Encoder) encObject(v POJO) error {
 		return nil
 	}
 
+	_, isEnum = v.(POJOEnum)
 	// write object definition
 	idx = -1
 	for i = range e.classInfoList {
-		if v.JavaClassName() == e.classInfoList[i].javaName {
+		if javaName == e.classInfoList[i].javaName {
 			idx = i
 			break
 		}
 	}
 	if idx == -1 {
-		idx, ok = checkPOJORegistry(typeof(v))
+		idx, ok = checkPOJORegistry(vv.Type().String())
 		if !ok { // 不存在
-			if reflect.TypeOf(v).Implements(javaEnumType) {
+			if isEnum {
 				idx = RegisterJavaEnum(v.(POJOEnum))
 			} else {
-				idx = RegisterPOJO(v)
+				idx = RegisterPOJOMapping(javaName, v)
 			}
+			if idx == -1 { // registered by another goroutine
+				if idx, ok = checkPOJORegistry(vv.Type().String()); !ok {
+					return jerrors.Errorf("java class %s has been registered by another go type", javaName)
+				}
+			}
+		}
+		if _, clsDef, err = getStructDefByIndex(idx); err != nil {
+			return jerrors.Trace(err)
 		}
-		_, clsDef, _ = getStructDefByIndex(idx)
 		idx = len(e.classInfoList)
 		e.classInfoList = append(e.classInfoList, clsDef)
 		e.buffer = append(e.buffer, clsDef.buffer...)
@@ -148,13 +151,18 @@ func (e *Encoder) encObject(v POJO) error {
 		e.buffer = encInt32(e.buffer, int32(idx))
 	}
 
-	if reflect.TypeOf(v).Implements(javaEnumType) {
+	if isEnum {
 		e.buffer = encString(e.buffer, v.(POJOEnum).String())
 		return nil
 	}
-	num = vv.NumField()
+	fields := getFields(vv.Type())
+	num = len(fields)
 	for i = 0; i < num; i++ {
-		field := vv.Field(i)
+		field := fieldByIndex(vv, fields[i].index, false)
+		if !field.IsValid() {
+			// the field of a nil embedded struct pointer
+			field = reflect.Zero(vv.Type().FieldByIndex(fields[i].index).Type)
+		}
 		fieldName := field.Type().String()
 		if err = e.Encode(field.Interface()); err != nil {
 			return jerrors.Annotatef(err, "failed to encode field: %s, %+v", fieldName, field.Interface())
@@ -262,20 +270,21 @@ func (d *Decoder) decClassDef() (interface{}, error) {
 	return classInfo{javaName: clsName, fieldNameList: fieldList}, nil
 }
 
-func findField(name string, typ reflect.Type) (int, error) {
-	for i := 0; i < typ.NumField(); i++ {
-		str := typ.Field(i).Name
-		if strings.Compare(str, name) == 0 {
-			return i, nil
+// findField returns the index sequence of the go struct field whose java field name or go field name is @name.
+func findField(name string, typ reflect.Type) ([]int, error) {
+	fields := getFields(typ)
+	for i := range fields {
+		if fields[i].name == name {
+			return fields[i].index, nil
 		}
-		// str1 := strings.Title(name)
-		str1 := strings.ToLower(str)
-		if strings.Compare(name, str1) == 0 {
-			return i, nil
+	}
+	for i := range fields {
+		if typ.FieldByIndex(fields[i].index).Name == name {
+			return fields[i].index, nil
 		}
 	}
 
-	return 0, jerrors.Errorf("failed to find field %s", name)
+	return nil, jerrors.Errorf("failed to find field %s", name)
 }
 
 func (d *Decoder) decInstance(typ reflect.Type, cls classInfo) (interface{}, error) {
@@ -285,148 +294,321 @@ func (d *Decoder) decInstance(typ reflect.Type, cls classInfo) (interface{}, err
 
 	vRef := reflect.New(typ)
 	// add pointer ref so that ref the same object
+	refIndex := len(d.refs)
 	d.appendRefs(vRef)
 
 	vv := vRef.Elem()
 	for i := 0; i < len(cls.fieldNameList); i++ {
-		fieldName := cls.fieldNameList[i]
+		if err := d.decField(vv, cls.javaName, cls.fieldNameList[i]); err != nil {
+			return nil, err
+		}
+	}
 
-		index, err := findField(fieldName, typ)
-		if err != nil {
-			return nil, jerrors.Errorf("can not find field %s", fieldName)
+	// the go type shared by several java classes, eg: java.lang.RuntimeException --> JavaThrowable
+	if c, ok := vRef.Interface().(classSetter); ok {
+		c.setJavaClass(cls.javaName)
+	}
+
+	// replace the java object with its go value, eg: java.math.BigInteger --> *big.Int
+	if r, ok := vRef.Interface().(resolver); ok {
+		v := r.resolve()
+		d.refs[refIndex] = v
+		return v, nil
+	}
+
+	return vRef, nil
+}
+
+// decField decodes the value of java field @fieldName of java class @javaName into go struct @vv.
+func (d *Decoder) decField(vv reflect.Value, javaName string, fieldName string) error {
+	index, err := findField(fieldName, vv.Type())
+	if err != nil {
+		if d.strict {
+			return jerrors.Errorf("can not find field %s", fieldName)
 		}
-		field := vv.Field(index)
-		if !field.CanSet() {
-			return nil, jerrors.Errorf("decInstance CanSet false for field %s", fieldName)
+		// skip the value of unknown field
+		if _, err = d.Decode(); err != nil {
+			return jerrors.Annotatef(err, "decInstance->skip field name:%s", fieldName)
 		}
+		d.addSkippedField(javaName, fieldName)
+		return nil
+	}
+	field := fieldByIndex(vv, index, true)
+	if !field.CanSet() {
+		return jerrors.Errorf("decInstance CanSet false for field %s", fieldName)
+	}
 
-		// get field type from type object, not do that from value
-		fldTyp := UnpackPtrType(field.Type())
+	// get field type from type object, not do that from value
+	fldTyp := UnpackPtrType(field.Type())
 
-		// unpack pointer to enable value setting
-		fldRawValue := UnpackPtrValue(field)
+	// unpack pointer to enable value setting
+	fldRawValue := UnpackPtrValue(field)
 
-		kind := fldTyp.Kind()
-		switch {
-		case kind == reflect.String:
-			str, err := d.decString(TAG_READ)
-			if err != nil {
-				return nil, jerrors.Annotatef(err, "decInstance->ReadString: %s", fieldName)
-			}
-			fldRawValue.SetString(str)
+	kind := fldTyp.Kind()
+	switch {
+	case kind == reflect.String:
+		str, err := d.decString(TAG_READ)
+		if err != nil {
+			return jerrors.Annotatef(err, "decInstance->ReadString: %s", fieldName)
+		}
+		fldRawValue.SetString(str)
 
-		case kind == reflect.Int32 || kind == reflect.Int16:
-			num, err := d.decInt32(TAG_READ)
-			if err != nil {
-				// java enum
-				if fldRawValue.Type().Implements(javaEnumType) {
-					d.unreadByte() // enum解析，上面decInt64已经读取一个字节，所以这里需要回退一个字节
-					s, err := d.Decode()
-					if err != nil {
-						return nil, jerrors.Annotatef(err, "decInstance->decObject field name:%s", fieldName)
-					}
-					enumValue, _ := s.(JavaEnum)
-					num = int32(enumValue)
-				} else {
-					return nil, jerrors.Annotatef(err, "decInstance->ParseInt, field name:%s", fieldName)
+	case kind == reflect.Int8 || kind == reflect.Int16 || kind == reflect.Int32:
+		num, err := d.decInt32(TAG_READ)
+		if err != nil {
+			// java enum
+			if fldRawValue.Type().Implements(javaEnumType) {
+				d.unreadByte() // enum解析，上面decInt64已经读取一个字节，所以这里需要回退一个字节
+				s, err := d.Decode()
+				if err != nil {
+					return jerrors.Annotatef(err, "decInstance->decObject field name:%s", fieldName)
 				}
+				enumValue, _ := s.(JavaEnum)
+				num = int32(enumValue)
+			} else {
+				return jerrors.Annotatef(err, "decInstance->ParseInt, field name:%s", fieldName)
 			}
+		}
+		if fldRawValue.OverflowInt(int64(num)) {
+			return jerrors.Errorf("value %d of field %s overflows %s", num, fieldName, fldTyp)
+		}
 
-			fldRawValue.SetInt(int64(num))
+		fldRawValue.SetInt(int64(num))
 
-		case kind == reflect.Int || kind == reflect.Int64 || kind == reflect.Uint64:
-			num, err := d.decInt64(TAG_READ)
-			if err != nil {
-				if fldTyp.Implements(javaEnumType) {
-					d.unreadByte() // enum解析，上面decInt64已经读取一个字节，所以这里需要回退一个字节
-					s, err := d.Decode()
-					if err != nil {
-						return nil, jerrors.Annotatef(err, "decInstance->decObject field name:%s", fieldName)
-					}
-					enumValue, _ := s.(JavaEnum)
-					num = int64(enumValue)
-				} else {
-					return nil, jerrors.Annotatef(err, "decInstance->decInt64 field name:%s", fieldName)
+	case kind == reflect.Int || kind == reflect.Int64:
+		num, err := d.decInt64(TAG_READ)
+		if err != nil {
+			if fldTyp.Implements(javaEnumType) {
+				d.unreadByte() // enum解析，上面decInt64已经读取一个字节，所以这里需要回退一个字节
+				s, err := d.Decode()
+				if err != nil {
+					return jerrors.Annotatef(err, "decInstance->decObject field name:%s", fieldName)
 				}
+				enumValue, _ := s.(JavaEnum)
+				num = int64(enumValue)
+			} else {
+				return jerrors.Annotatef(err, "decInstance->decInt64 field name:%s", fieldName)
 			}
+		}
+		if fldRawValue.OverflowInt(num) {
+			return jerrors.Errorf("value %d of field %s overflows %s", num, fieldName, fldTyp)
+		}
 
-			fldRawValue.SetInt(num)
+		fldRawValue.SetInt(num)
 
-		case kind == reflect.Bool:
-			b, err := d.Decode()
-			if err != nil {
-				return nil, jerrors.Annotatef(err, "decInstance->Decode field name:%s", fieldName)
-			}
-			fldRawValue.SetBool(b.(bool))
+	case kind == reflect.Uint || kind == reflect.Uint8 || kind == reflect.Uint16 ||
+		kind == reflect.Uint32 || kind == reflect.Uint64:
+		// unsigned integers are encoded as hessian int or long
+		num, err := d.decInt64(TAG_READ)
+		if err != nil {
+			return jerrors.Annotatef(err, "decInstance->decInt64 field name:%s", fieldName)
+		}
+		if num < 0 || fldRawValue.OverflowUint(uint64(num)) {
+			return jerrors.Errorf("value %d of field %s overflows %s", num, fieldName, fldTyp)
+		}
 
-		case kind == reflect.Float32 || kind == reflect.Float64:
-			num, err := d.decDouble(TAG_READ)
-			if err != nil {
-				return nil, jerrors.Annotatef(err, "decInstance->decDouble field name:%s", fieldName)
-			}
-			fldRawValue.SetFloat(num.(float64))
+		fldRawValue.SetUint(uint64(num))
 
-		case kind == reflect.Map:
-			// decode map should use the original field value for correct value setting
-			err := d.decMapByValue(field)
-			if err != nil {
-				return nil, jerrors.Annotatef(err, "decInstance->decMapByValue field name: %s", fieldName)
+	case kind == reflect.Bool:
+		b, err := d.Decode()
+		if err != nil {
+			return jerrors.Annotatef(err, "decInstance->Decode field name:%s", fieldName)
+		}
+		fldRawValue.SetBool(b.(bool))
+
+	case kind == reflect.Float32 || kind == reflect.Float64:
+		num, err := d.decDouble(TAG_READ)
+		if err != nil {
+			return jerrors.Annotatef(err, "decInstance->decDouble field name:%s", fieldName)
+		}
+		fldRawValue.SetFloat(num)
+
+	case kind == reflect.Map:
+		// decode map should use the original field value for correct value setting
+		err := d.decMapByValue(field)
+		if err != nil {
+			return jerrors.Annotatef(err, "decInstance->decMapByValue field name: %s", fieldName)
+		}
+
+	case kind == reflect.Slice || kind == reflect.Array:
+		m, err := d.decList(TAG_READ)
+		if err != nil {
+			if err == io.EOF {
+				break
 			}
+			return jerrors.Trace(err)
+		}
 
-		case kind == reflect.Slice || kind == reflect.Array:
-			m, err := d.decList(TAG_READ)
+		// set slice separately
+		err = SetSlice(fldRawValue, m)
+		if err != nil {
+			return err
+		}
+	case kind == reflect.Struct:
+		var (
+			err error
+			s   interface{}
+		)
+		if fldRawValue.Type().String() == "time.Time" {
+			s, err = d.decDate(TAG_READ)
 			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				return nil, jerrors.Trace(err)
+				return jerrors.Trace(err)
 			}
-
-			// set slice separately
-			err = SetSlice(fldRawValue, m)
+			fldRawValue.Set(reflect.ValueOf(s))
+		} else {
+			s, err = d.decObject(TAG_READ)
 			if err != nil {
-				return nil, err
+				return jerrors.Trace(err)
 			}
-		case kind == reflect.Struct:
-			var (
-				err error
-				s   interface{}
-			)
-			if fldRawValue.Type().String() == "time.Time" {
-				s, err = d.decDate(TAG_READ)
-				if err != nil {
-					return nil, jerrors.Trace(err)
-				}
-				fldRawValue.Set(reflect.ValueOf(s))
-			} else {
-				s, err = d.decObject(TAG_READ)
-				if err != nil {
-					return nil, jerrors.Trace(err)
-				}
-				if s != nil {
-					// set value which accepting pointers
-					SetValue(fldRawValue, EnsurePackValue(s))
-				}
+			if obj, ok := s.(*JavaObject); ok {
+				return jerrors.Errorf("can not decode java object %s into field %s of type %s",
+					obj.ClassName, fieldName, fldRawValue.Type())
 			}
+			if s != nil {
+				// set value which accepting pointers
+				SetValue(fldRawValue, EnsurePackValue(s))
+			}
+		}
+
+	default:
+		return jerrors.Errorf("unknown struct member type: %v", kind)
+	}
 
-		default:
-			return nil, jerrors.Errorf("unknown struct member type: %v", kind)
+	return nil
+}
+
+// JavaObject is the object of a java class which has no registered go type. It keeps the
+// class def and the field values, so that it is encoded again with the same java class
+// and field names. The values may be written in other forms than java did, eg: a variable
+// length list is written as a fixed length one.
+type JavaObject struct {
+	ClassName string        // java class name
+	Fields    []string      // java field names
+	Values    []interface{} // field values
+}
+
+// decJavaObject decodes the object of class def @cls which has no go type as a *JavaObject,
+// or as a map[string]interface{} if the decoder decodes such objects as maps.
+func (d *Decoder) decJavaObject(cls classInfo) (interface{}, error) {
+	if d.objectAsMap {
+		return d.decObjectAsMap(cls)
+	}
+
+	obj := &JavaObject{
+		ClassName: cls.javaName,
+		Fields:    cls.fieldNameList,
+		Values:    make([]interface{}, len(cls.fieldNameList)),
+	}
+	d.appendRefs(obj)
+
+	for i := range obj.Values {
+		v, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Annotatef(unexpectedEOF(err), "decJavaObject->field name:%s", cls.fieldNameList[i])
 		}
-	} // end for
+		obj.Values[i] = unpackDecodedValue(v)
+	}
 
-	return vRef, nil
+	return obj, nil
+}
+
+func (d *Decoder) decObjectAsMap(cls classInfo) (interface{}, error) {
+	m := make(map[string]interface{}, len(cls.fieldNameList)+1)
+	m[JAVA_CLASS_KEY] = cls.javaName
+	d.appendRefs(m)
+
+	for _, fieldName := range cls.fieldNameList {
+		v, err := d.Decode()
+		if err != nil {
+			return nil, jerrors.Annotatef(unexpectedEOF(err), "decObjectAsMap->field name:%s", fieldName)
+		}
+		m[fieldName] = unpackDecodedValue(v)
+	}
+
+	return m, nil
+}
+
+func (e *Encoder) encJavaObject(obj *JavaObject) error {
+	if obj == nil {
+		e.buffer = encNull(e.buffer)
+		return nil
+	}
+	if len(obj.Fields) != len(obj.Values) {
+		return jerrors.Errorf("java object %s has %d fields but %d values",
+			obj.ClassName, len(obj.Fields), len(obj.Values))
+	}
+
+	// check ref
+	if n, ok := e.checkRefMap(reflect.ValueOf(obj)); ok {
+		e.buffer = encRef(e.buffer, n)
+		return nil
+	}
+
+	e.encObjectHead(obj.ClassName, obj.Fields)
+	for i, v := range obj.Values {
+		if err := e.Encode(v); err != nil {
+			return jerrors.Annotatef(err, "failed to encode field: %s, %+v", obj.Fields[i], v)
+		}
+	}
+
+	return nil
+}
+
+// encObjectHead writes the class def of java class @javaName whose fields are @fields if it
+// has not been written, and then the head of an object of the class.
+func (e *Encoder) encObjectHead(javaName string, fields []string) {
+	idx := -1
+	for i := range e.classInfoList {
+		if javaName == e.classInfoList[i].javaName {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		b := encByte(nil, BC_OBJECT_DEF)
+		b = encString(b, javaName)
+		b = encInt32(b, int32(len(fields)))
+		for _, f := range fields {
+			b = encString(b, f)
+		}
+
+		idx = len(e.classInfoList)
+		e.classInfoList = append(e.classInfoList, classInfo{javaName: javaName, fieldNameList: fields, buffer: b})
+		e.buffer = append(e.buffer, b...)
+	}
+
+	if idx <= int(OBJECT_DIRECT_MAX) {
+		e.buffer = encByte(e.buffer, byte(idx)+BC_OBJECT_DIRECT)
+	} else {
+		e.buffer = encByte(e.buffer, BC_OBJECT)
+		e.buffer = encInt32(e.buffer, int32(idx))
+	}
+}
+
+// resolver is implemented by the internal POJOs which are decoded as other go values
+type resolver interface {
+	resolve() interface{}
+}
+
+var _resolverType = reflect.TypeOf((*resolver)(nil)).Elem()
+
+// classSetter is implemented by the internal POJOs which are decoded from several java classes,
+// it is called with the java class name after the fields are decoded
+type classSetter interface {
+	setJavaClass(javaName string)
 }
 
 func (d *Decoder) appendClsDef(cd classInfo) {
 	d.classInfoList = append(d.classInfoList, cd)
 }
 
+// getStructDefByIndex returns the go type and the class def @idx of the decoded class defs.
+// The go type is nil if the java class has not been registered, except for java exceptions.
 func (d *Decoder) getStructDefByIndex(idx int) (reflect.Type, classInfo, error) {
 	var (
-		ok      bool
-		clsName string
-		cls     classInfo
-		s       structInfo
+		ok  bool
+		cls classInfo
+		s   structInfo
 	)
 
 	if len(d.classInfoList) <= idx || idx < 0 {
@@ -434,8 +616,11 @@ func (d *Decoder) getStructDefByIndex(idx int) (reflect.Type, classInfo, error)
 	}
 	cls = d.classInfoList[idx]
 	s, ok = getStructInfo(cls.javaName)
+	if !ok && isJavaThrowable(cls) {
+		s, ok = getStructInfo(javaThrowable)
+	}
 	if !ok {
-		return nil, cls, jerrors.Errorf("can not find go type name %s in registry", clsName)
+		return nil, cls, nil
 	}
 
 	return s.typ, cls, nil
@@ -475,7 +660,10 @@ func (d *Decoder) decObject(flag int32) (interface{}, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return nil, jerrors.Trace(err)
+		}
 	}
 
 	switch {
@@ -504,6 +692,9 @@ func (d *Decoder) decObject(flag int32) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
+		if typ == nil {
+			return d.decJavaObject(cls)
+		}
 		if typ.Implements(javaEnumType) {
 			return d.decEnum(cls.javaName, TAG_READ)
 		}
@@ -515,6 +706,9 @@ func (d *Decoder) decObject(flag int32) (interface{}, error) {
 		if err != nil {
 			return nil, err
 		}
+		if typ == nil {
+			return d.decJavaObject(cls)
+		}
 		if typ.Implements(javaEnumType) {
 			return d.decEnum(cls.javaName, TAG_READ)
 		}
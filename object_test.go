@@ -17,6 +17,7 @@ package hessian
 import (
 	"reflect"
 	"testing"
+	"time"
 )
 
 type Department struct {
@@ -198,3 +199,544 @@ func TestIssue6(t *testing.T) {
 		t.Fatalf("worker:%#v != worker2:%#v", worker, worker2)
 	}
 }
+
+type TaggedUser struct {
+	UserName string `hessian:"userName"`
+	UserAge  int32  `hessian:"userAge"`
+	Password string `hessian:"-"`
+	Remark   string
+}
+
+func (TaggedUser) JavaClassName() string {
+	return "com.bdt.info.TaggedUser"
+}
+
+func TestTaggedStruct(t *testing.T) {
+	u := TaggedUser{UserName: "dubbo", UserAge: 18, Password: "secret", Remark: "go"}
+
+	e := NewEncoder()
+	err := e.Encode(u)
+	if err != nil {
+		t.Fatalf("encode(user:%#v) = error:%s", u, err)
+	}
+
+	s, ok := getStructInfo(u.JavaClassName())
+	if !ok {
+		t.Fatalf("%s has not been registered", u.JavaClassName())
+	}
+	_, cls, err := getStructDefByIndex(s.index)
+	if err != nil {
+		t.Fatalf("getStructDefByIndex(%d) = error:%s", s.index, err)
+	}
+	if !reflect.DeepEqual(cls.fieldNameList, []string{"userName", "userAge", "remark"}) {
+		t.Fatalf("wrong java field name list %v", cls.fieldNameList)
+	}
+
+	res, err := NewDecoder(e.Buffer()).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	u2, ok := res.(reflect.Value).Interface().(*TaggedUser)
+	if !ok {
+		t.Fatalf("res:%#v is not of type TaggedUser", res)
+	}
+
+	u.Password = ""
+	if !reflect.DeepEqual(u, *u2) {
+		t.Fatalf("user:%#v != user2:%#v", u, u2)
+	}
+}
+
+func TestLowerCamelCase(t *testing.T) {
+	cases := map[string]string{
+		"A":           "a",
+		"ID":          "id",
+		"Age":         "age",
+		"UserName":    "userName",
+		"URLPath":     "urlPath",
+		"HTTPServer2": "httpServer2",
+		"userName":    "userName",
+		"ID2":         "id2",
+	}
+	for goName, javaName := range cases {
+		if n := LowerCamelCase(goName); n != javaName {
+			t.Errorf("LowerCamelCase(%s) = %s, want %s", goName, n, javaName)
+		}
+	}
+}
+
+type LowerCaseUser struct {
+	UserName string
+	ID       int64
+}
+
+func (LowerCaseUser) JavaClassName() string {
+	return "com.bdt.info.LowerCaseUser"
+}
+
+func TestFieldNameStrategy(t *testing.T) {
+	SetFieldNameStrategy(LowerCase)
+	defer SetFieldNameStrategy(nil)
+
+	u := LowerCaseUser{UserName: "dubbo", ID: 1}
+	idx := RegisterPOJO(u)
+	_, cls, err := getStructDefByIndex(idx)
+	if err != nil {
+		t.Fatalf("getStructDefByIndex(%d) = error:%s", idx, err)
+	}
+	if !reflect.DeepEqual(cls.fieldNameList, []string{"username", "id"}) {
+		t.Fatalf("wrong java field name list %v", cls.fieldNameList)
+	}
+
+	e := NewEncoder()
+	if err = e.Encode(u); err != nil {
+		t.Fatalf("encode(user:%#v) = error:%s", u, err)
+	}
+	res, err := NewDecoder(e.Buffer()).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if u2 := res.(reflect.Value).Interface().(*LowerCaseUser); !reflect.DeepEqual(u, *u2) {
+		t.Fatalf("user:%#v != user2:%#v", u, u2)
+	}
+
+	// the fields of the seen types and the registered class defs follow the new strategy
+	SetFieldNameStrategy(LowerCamelCase)
+	if _, cls, err = getStructDefByIndex(idx); err != nil {
+		t.Fatalf("getStructDefByIndex(%d) = error:%s", idx, err)
+	}
+	if !reflect.DeepEqual(cls.fieldNameList, []string{"userName", "id"}) {
+		t.Fatalf("wrong java field name list %v", cls.fieldNameList)
+	}
+	if f := getFields(reflect.TypeOf(u)); f[0].name != "userName" {
+		t.Fatalf("wrong java field name %s", f[0].name)
+	}
+
+	// change the strategy while encoding
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			if err := NewEncoder().Encode(u); err != nil {
+				t.Errorf("encode(user:%#v) = error:%s", u, err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		SetFieldNameStrategy(LowerCase)
+	}
+	<-done
+}
+
+type DriftUser struct {
+	Name string
+}
+
+func (DriftUser) JavaClassName() string {
+	return "com.bdt.info.DriftUser"
+}
+
+// encode a DriftUser whose java class has two more fields "email" and "tags"
+func encDriftUser() []byte {
+	var b []byte
+	b = encByte(b, BC_OBJECT_DEF)
+	b = encString(b, DriftUser{}.JavaClassName())
+	b = encInt32(b, 3)
+	b = encString(b, "email")
+	b = encString(b, "name")
+	b = encString(b, "tags")
+	b = encByte(b, BC_OBJECT_DIRECT)
+	b = encString(b, "dubbo@apache.org")
+	b = encString(b, "dubbo")
+	b = encByte(b, BC_LIST_FIXED_UNTYPED)
+	b = encInt32(b, 2)
+	b = encString(b, "go")
+	b = encString(b, "java")
+	b = encByte(b, BC_OBJECT_DIRECT)
+	b = encNull(b)
+	b = encString(b, "dubbo-go")
+	b = encNull(b)
+	return b
+}
+
+func TestSkipUnknownField(t *testing.T) {
+	RegisterPOJO(&DriftUser{})
+
+	d := NewDecoder(encDriftUser())
+	res, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	u, ok := res.(reflect.Value).Interface().(*DriftUser)
+	if !ok || u.Name != "dubbo" {
+		t.Fatalf("wrong decode result %#v", res)
+	}
+	res, err = d.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	u, ok = res.(reflect.Value).Interface().(*DriftUser)
+	if !ok || u.Name != "dubbo-go" {
+		t.Fatalf("wrong decode result %#v", res)
+	}
+
+	skipped := map[string][]string{DriftUser{}.JavaClassName(): {"email", "tags"}}
+	if !reflect.DeepEqual(skipped, d.SkippedFields()) {
+		t.Fatalf("skipped fields %v != %v", d.SkippedFields(), skipped)
+	}
+
+	d = NewDecoder(encDriftUser())
+	d.SetStrict(true)
+	if _, err = d.Decode(); err == nil {
+		t.Fatalf("strict Decode() should fail on unknown field")
+	}
+}
+
+type BaseEntity struct {
+	ID      int64
+	Creator string
+}
+
+type Audit struct {
+	Remark string
+}
+
+type Order struct {
+	BaseEntity
+	*Audit
+	OrderNo string
+	Creator string // hides BaseEntity.Creator
+	amount  int64
+}
+
+func (Order) JavaClassName() string {
+	return "com.bdt.info.Order"
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	orders := []Order{
+		{
+			BaseEntity: BaseEntity{ID: 1},
+			Audit:      &Audit{Remark: "vip"},
+			OrderNo:    "20190520",
+			Creator:    "dubbo",
+			amount:     100,
+		},
+		{
+			BaseEntity: BaseEntity{ID: 2},
+			OrderNo:    "20190521",
+		},
+	}
+
+	for _, o := range orders {
+		e := NewEncoder()
+		err := e.Encode(o)
+		if err != nil {
+			t.Fatalf("encode(order:%#v) = error:%s", o, err)
+		}
+
+		s, _ := getStructInfo(o.JavaClassName())
+		_, cls, _ := getStructDefByIndex(s.index)
+		if !reflect.DeepEqual(cls.fieldNameList, []string{"id", "remark", "orderNo", "creator"}) {
+			t.Fatalf("wrong java field name list %v", cls.fieldNameList)
+		}
+
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		o2 := res.(reflect.Value).Interface().(*Order)
+		if o.Audit == nil {
+			// the embedded pointer is allocated when decoding
+			o.Audit = &Audit{}
+		}
+		o.amount = 0
+		if !reflect.DeepEqual(o, *o2) {
+			t.Fatalf("order:%#v != order2:%#v", o, o2)
+		}
+	}
+}
+
+// LinkedItem embeds itself, which is not flattened again
+type LinkedItem struct {
+	*LinkedItem
+	Value string
+}
+
+type Event struct {
+	time.Time // encoded as one field
+	LinkedItem
+	Department // POJO, encoded as one field
+	Name       string
+}
+
+func (Event) JavaClassName() string {
+	return "com.bdt.info.Event"
+}
+
+func TestEmbeddedValueStruct(t *testing.T) {
+	fields := getFields(reflect.TypeOf(LinkedItem{}))
+	if len(fields) != 1 || fields[0].name != "value" {
+		t.Fatalf("wrong fields of LinkedItem %v", fields)
+	}
+
+	ev := Event{
+		Time:       time.Date(2019, 5, 20, 10, 0, 0, 0, time.UTC),
+		LinkedItem: LinkedItem{Value: "item"},
+		Department: Department{Name: "dubbo"},
+		Name:       "release",
+	}
+	e := NewEncoder()
+	if err := e.Encode(ev); err != nil {
+		t.Fatalf("encode(event:%#v) = error:%s", ev, err)
+	}
+
+	s, _ := getStructInfo(ev.JavaClassName())
+	_, cls, _ := getStructDefByIndex(s.index)
+	if !reflect.DeepEqual(cls.fieldNameList, []string{"time", "value", "department", "name"}) {
+		t.Fatalf("wrong java field name list %v", cls.fieldNameList)
+	}
+
+	res, err := NewDecoder(e.Buffer()).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	ev2 := res.(reflect.Value).Interface().(*Event)
+	if !ev.Time.Equal(ev2.Time) || ev.LinkedItem != ev2.LinkedItem || ev.Department != ev2.Department || ev.Name != ev2.Name {
+		t.Fatalf("event:%#v != event2:%#v", ev, ev2)
+	}
+}
+
+// MappedUser does not implement POJO, it is registered by RegisterPOJOMapping
+type MappedUser struct {
+	Name string
+	Age  int32
+}
+
+// ClassTagUser does not implement POJO, its java class name is set by the blank field tag
+type ClassTagUser struct {
+	_    struct{} `hessian:"com.bdt.info.ClassTagUser"`
+	Name string
+}
+
+type plainUser struct {
+	Name string
+	Age  int32
+}
+
+func TestPlainStruct(t *testing.T) {
+	RegisterPOJOMapping("com.bdt.info.MappedUser", &MappedUser{})
+
+	for _, v := range []interface{}{
+		&MappedUser{Name: "dubbo", Age: 18},
+		&ClassTagUser{Name: "go"},
+	} {
+		e := NewEncoder()
+		if err := e.Encode(v); err != nil {
+			t.Fatalf("encode(%#v) = error:%s", v, err)
+		}
+		res, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("Decode() = %v", err)
+		}
+		if !reflect.DeepEqual(v, res.(reflect.Value).Interface()) {
+			t.Fatalf("%#v != %#v", v, res.(reflect.Value).Interface())
+		}
+	}
+
+	s, ok := getStructInfo("com.bdt.info.ClassTagUser")
+	if !ok || s.typ != reflect.TypeOf(ClassTagUser{}) {
+		t.Fatalf("ClassTagUser is not registered by its tag")
+	}
+
+	// another go type can not be registered as the same java class
+	if idx := RegisterPOJOMapping("com.bdt.info.MappedUser", &plainUser{}); idx != -1 {
+		t.Fatalf("RegisterPOJOMapping(plainUser) = %d, want -1", idx)
+	}
+	if s, ok = getStructInfo("com.bdt.info.MappedUser"); !ok || s.typ != reflect.TypeOf(MappedUser{}) {
+		t.Fatalf("com.bdt.info.MappedUser is registered as %v", s.typ)
+	}
+	if err := NewEncoder().Encode(plainUserWithClass{}); err == nil {
+		t.Fatalf("encode(plainUserWithClass) should fail")
+	}
+
+	// the java exceptions can be registered as other go types
+	if idx := RegisterPOJOMapping("java.lang.ArithmeticException", &arithmeticError{}); idx == -1 {
+		t.Fatalf("RegisterPOJOMapping(java.lang.ArithmeticException) = -1")
+	}
+}
+
+type arithmeticError struct {
+	DetailMessage string
+}
+
+// plainUserWithClass sets the java class name of MappedUser
+type plainUserWithClass struct {
+	_    struct{} `hessian:"com.bdt.info.MappedUser"`
+	Name string
+}
+
+func TestUntypedStruct(t *testing.T) {
+	u := plainUser{Name: "dubbo", Age: 18}
+
+	e := NewEncoder()
+	if err := e.Encode(u); err == nil {
+		t.Fatalf("encode(%#v) should fail by default", u)
+	}
+
+	e = NewEncoder()
+	e.SetUntypedStruct(true)
+	if err := e.Encode([]interface{}{&u, &u}); err != nil {
+		t.Fatalf("encode(%#v) = error:%s", u, err)
+	}
+
+	var users []plainUser
+	if err := NewDecoder(e.Buffer()).DecodeValue(&users); err != nil {
+		t.Fatalf("DecodeValue() = error:%s", err)
+	}
+	if !reflect.DeepEqual(users, []plainUser{u, u}) {
+		t.Fatalf("%#v != %#v", users, []plainUser{u, u})
+	}
+
+	res, err := NewDecoder(e.Buffer()).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = %v", err)
+	}
+	m := res.(*_refHolder).value.Interface().([]interface{})[0]
+	if !reflect.DeepEqual(m, map[interface{}]interface{}{"name": "dubbo", "age": int32(18)}) {
+		t.Fatalf("wrong untyped map %#v", m)
+	}
+}
+
+func TestJavaObject(t *testing.T) {
+	obj := &JavaObject{
+		ClassName: "test.Unknown",
+		Fields:    []string{"name", "age"},
+		Values:    []interface{}{"dubbo", int32(18)},
+	}
+
+	e := NewEncoder()
+	if err := e.Encode([]interface{}{obj, obj, *obj}); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", obj, err)
+	}
+	want := append([]byte{0x7b, 'C', 0x0c}, "test.Unknown"...)
+	want = append(want, 0x92, 0x04, 'n', 'a', 'm', 'e', 0x03, 'a', 'g', 'e')
+	want = append(want, 0x60, 0x05, 'd', 'u', 'b', 'b', 'o', 0xa2)
+	want = append(want, 0x51, 0x91)
+	want = append(want, 0x60, 0x05, 'd', 'u', 'b', 'b', 'o', 0xa2)
+	assertEqual(want, e.Buffer(), t)
+
+	res, err := EnsureInterface(NewDecoder(want).Decode())
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+	objs := res.([]interface{})
+	if !reflect.DeepEqual(objs[0], obj) || objs[0] != objs[1] || !reflect.DeepEqual(objs[2], obj) {
+		t.Fatalf("Decode() = %#v", objs)
+	}
+
+	// encode the decoded objects again
+	e = NewEncoder()
+	if err = e.Encode(res); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", res, err)
+	}
+	assertEqual(want, e.Buffer(), t)
+
+	// circular reference
+	cons := &JavaObject{ClassName: "test.Cons", Fields: []string{"first", "rest"}, Values: []interface{}{"a", nil}}
+	cons.Values[1] = cons
+	e = NewEncoder()
+	if err = e.Encode(cons); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", cons, err)
+	}
+	res, err = NewDecoder(e.Buffer()).Decode()
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+	if c, ok := res.(*JavaObject); !ok || c.Values[0] != "a" || c.Values[1] != c {
+		t.Fatalf("Decode() = %#v", res)
+	}
+
+	obj.Values = obj.Values[:1]
+	if err = NewEncoder().Encode(obj); err == nil {
+		t.Errorf("Encode(%#v) should fail", obj)
+	}
+}
+
+func TestJavaObjectJava(t *testing.T) {
+	for _, n := range []string{"0", "1", "16", "2", "2a", "2b", "3"} {
+		method := "replyObject_" + n
+		res, err := decodeResponse(method)
+		if err != nil {
+			t.Errorf("%s: decode fail with error %v", method, err)
+			continue
+		}
+		res, _ = EnsureInterface(res, nil)
+
+		// byte for byte the same as java
+		e := NewEncoder()
+		if err = e.Encode(res); err != nil {
+			t.Errorf("%s: encode fail with error %v", method, err)
+			continue
+		}
+		assertEqual(getReply(method), e.Buffer(), t)
+
+		testJavaDecode(t, "argObject_"+n, res)
+	}
+
+	d := NewDecoder(getReply("replyObject_1"))
+	d.SetUnregisteredObjectAsMap(true)
+	res, err := d.Decode()
+	if err != nil {
+		t.Fatalf("replyObject_1: decode fail with error %v", err)
+	}
+	if m, ok := res.(map[string]interface{}); !ok || m[JAVA_CLASS_KEY] != "com.caucho.hessian.test.TestObject" {
+		t.Errorf("replyObject_1: got %#v", res)
+	}
+}
+
+func TestUnregisteredObjectAsMap(t *testing.T) {
+	inner := &JavaObject{ClassName: "test.Inner", Fields: []string{"id"}, Values: []interface{}{int64(1)}}
+	outer := &JavaObject{
+		ClassName: "test.Outer",
+		Fields:    []string{"name", "inner", "same"},
+		Values:    []interface{}{"dubbo", inner, inner},
+	}
+	e := NewEncoder()
+	if err := e.Encode(outer); err != nil {
+		t.Fatalf("Encode(%#v) = error:%s", outer, err)
+	}
+
+	d := NewDecoder(e.Buffer())
+	d.SetUnregisteredObjectAsMap(true)
+	res, err := d.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+	innerMap := map[string]interface{}{JAVA_CLASS_KEY: "test.Inner", "id": int64(1)}
+	expected := map[string]interface{}{
+		JAVA_CLASS_KEY: "test.Outer",
+		"name":         "dubbo",
+		"inner":        innerMap,
+		"same":         innerMap,
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Fatalf("Decode() = %#v, want %#v", res, expected)
+	}
+
+	// the registered classes are decoded as their go structs
+	e = NewEncoder()
+	if err = e.Encode(&Department{Name: "dubbo"}); err != nil {
+		t.Fatalf("Encode() = error:%s", err)
+	}
+	d = NewDecoder(e.Buffer())
+	d.SetUnregisteredObjectAsMap(true)
+	res, err = d.Decode()
+	if err != nil {
+		t.Fatalf("Decode() = error:%s", err)
+	}
+	if !reflect.DeepEqual(EnsureRawValue(res).Interface(), &Department{Name: "dubbo"}) {
+		t.Fatalf("Decode() = %#v", res)
+	}
+}
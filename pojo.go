@@ -16,9 +16,13 @@ package hessian
 
 import (
 	"fmt"
+	"math/big"
 	"reflect"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
+	"unicode"
 )
 
 import (
@@ -29,6 +33,21 @@ const (
 	InvalidJavaEnum JavaEnum = -1
 )
 
+// tagIdentifier is the struct tag key to set the java field name of a POJO field,
+// eg: `hessian:"userName"`. A field tagged with `hessian:"-"` is ignored.
+const tagIdentifier = "hessian"
+
+// FieldNameStrategy converts a go struct field name into the java field name
+// of a POJO field which has no hessian tag.
+type FieldNameStrategy func(goName string) string
+
+var (
+	// LowerCamelCase is the java bean naming, eg: UserName -> userName, ID -> id, URLPath -> urlPath.
+	LowerCamelCase FieldNameStrategy = lowerCamelCase
+	// LowerCase is the naming of old versions, eg: UserName -> username.
+	LowerCase FieldNameStrategy = strings.ToLower
+)
+
 // !!! Pls attention that Every field name should be upper case.
 // Otherwise the app may panic.
 type POJO interface {
@@ -53,6 +72,12 @@ type classInfo struct {
 	buffer        []byte // encoded buffer
 }
 
+// fieldInfo maps a go struct field to its java field name.
+type fieldInfo struct {
+	name  string // java field name
+	index []int  // go struct field index sequence, see reflect.Type.FieldByIndex
+}
+
 type structInfo struct {
 	typ      reflect.Type
 	goName   string
@@ -73,10 +98,82 @@ var (
 		j2g:      make(map[string]string),
 		registry: make(map[string]structInfo),
 	}
+	fieldsCache  sync.Map     // fieldsKey --> []fieldInfo
+	fieldNaming  atomic.Value // *fieldNamingInfo
 	pojoType     = reflect.TypeOf((*POJO)(nil)).Elem()
 	javaEnumType = reflect.TypeOf((*POJOEnum)(nil)).Elem()
+
+	// the struct types encoded as one value, which are not flattened when embedded
+	valueStructTypes = map[reflect.Type]bool{
+		reflect.TypeOf(time.Time{}):  true,
+		reflect.TypeOf(big.Int{}):    true,
+		reflect.TypeOf(JavaObject{}): true,
+		reflect.TypeOf(JavaList{}):   true,
+		reflect.TypeOf(JavaSet{}):    true,
+		reflect.TypeOf(JavaMap{}):    true,
+	}
 )
 
+// fieldNamingInfo is the current FieldNameStrategy. Its version is increased on
+// each change, so that the fields got by the previous strategy are not used.
+type fieldNamingInfo struct {
+	strategy FieldNameStrategy
+	version  int
+}
+
+// getFieldNaming returns the current FieldNameStrategy, LowerCamelCase by default.
+func getFieldNaming() *fieldNamingInfo {
+	if n, ok := fieldNaming.Load().(*fieldNamingInfo); ok {
+		return n
+	}
+	return &fieldNamingInfo{strategy: LowerCamelCase}
+}
+
+// fieldsKey is the key of fieldsCache.
+type fieldsKey struct {
+	typ     reflect.Type
+	version int // version of fieldNamingInfo
+}
+
+// SetFieldNameStrategy sets the naming of POJO fields which have no hessian tag.
+// The class defs of the registered POJOs are rebuilt by the new strategy, but
+// the encoders and decoders in use keep the class defs they have written or read.
+func SetFieldNameStrategy(s FieldNameStrategy) {
+	if s == nil {
+		s = LowerCamelCase
+	}
+
+	pojoRegistry.Lock()
+	defer pojoRegistry.Unlock()
+	n := getFieldNaming()
+	fieldNaming.Store(&fieldNamingInfo{strategy: s, version: n.version + 1})
+	fieldsCache.Range(func(k, _ interface{}) bool {
+		fieldsCache.Delete(k)
+		return true
+	})
+
+	for _, t := range pojoRegistry.registry {
+		if _, ok := t.inst.(POJOEnum); ok {
+			continue
+		}
+		pojoRegistry.classInfoList[t.index] = newClassInfo(t.javaName, t.typ)
+	}
+}
+
+// lowerCamelCase lowers the leading upper case letters of @name like java bean property names.
+// The last one of the leading upper case letters is kept if it starts the next word.
+func lowerCamelCase(name string) string {
+	r := []rune(name)
+	for i := 0; i < len(r) && unicode.IsUpper(r[i]); i++ {
+		if i > 0 && i+1 < len(r) && unicode.IsLower(r[i+1]) {
+			break
+		}
+		r[i] = unicode.ToLower(r[i])
+	}
+
+	return string(r)
+}
+
 // 解析struct
 func showPOJORegistry() {
 	pojoRegistry.Lock()
@@ -89,15 +186,16 @@ func showPOJORegistry() {
 
 // Register a POJO instance. The return value is -1 if @o has been registered.
 func RegisterPOJO(o POJO) int {
-	// # definition for an object (compact map)
-	// class-def  ::= 'C' string int string*
+	return RegisterPOJOMapping(o.JavaClassName(), o)
+}
+
+// RegisterPOJOMapping registers the go struct of @o, which need not implement POJO,
+// as java class @javaClassName. The return value is -1 if @o has been registered,
+// or if another go struct has been registered as @javaClassName.
+func RegisterPOJOMapping(javaClassName string, o interface{}) int {
 	var (
 		ok bool
-		b  []byte
 		i  int
-		n  int
-		f  string
-		l  []string
 		t  structInfo
 		c  classInfo
 		v  reflect.Value
@@ -105,8 +203,8 @@ func RegisterPOJO(o POJO) int {
 
 	pojoRegistry.Lock()
 	defer pojoRegistry.Unlock()
-	if _, ok = pojoRegistry.registry[o.JavaClassName()]; !ok {
-		v = reflect.ValueOf(o)
+	v = reflect.ValueOf(o)
+	if _, ok = pojoRegistry.registry[UnpackPtrType(v.Type()).String()]; !ok {
 		switch v.Kind() {
 		case reflect.Struct:
 			t.typ = v.Type()
@@ -116,24 +214,15 @@ func RegisterPOJO(o POJO) int {
 			t.typ = reflect.TypeOf(o)
 		}
 		t.goName = t.typ.String()
-		t.javaName = o.JavaClassName()
+		t.javaName = javaClassName
 		t.inst = o
-		pojoRegistry.j2g[t.javaName] = t.goName
-
-		b = b[:0]
-		b = encByte(b, BC_OBJECT_DEF)
-		b = encString(b, t.javaName)
-		l = l[:0]
-		n = t.typ.NumField()
-		b = encInt32(b, int32(n))
-		for i = 0; i < n; i++ {
-			f = strings.ToLower(t.typ.Field(i).Name)
-			l = append(l, f)
-			b = encString(b, f)
+		// the java exceptions decoded as JavaThrowable by default can be registered again
+		if g, ok := pojoRegistry.j2g[t.javaName]; ok && pojoRegistry.registry[g].javaName == t.javaName {
+			return -1
 		}
+		pojoRegistry.j2g[t.javaName] = t.goName
 
-		c = classInfo{javaName: t.javaName, fieldNameList: l}
-		c.buffer = append(c.buffer, b[:]...)
+		c = newClassInfo(t.javaName, t.typ)
 		t.index = len(pojoRegistry.classInfoList)
 		pojoRegistry.classInfoList = append(pojoRegistry.classInfoList, c)
 		pojoRegistry.registry[t.goName] = t
@@ -145,6 +234,168 @@ func RegisterPOJO(o POJO) int {
 	return i
 }
 
+// newClassInfo returns the class def of go struct type @typ as java class @javaName.
+func newClassInfo(javaName string, typ reflect.Type) classInfo {
+	// # definition for an object (compact map)
+	// class-def  ::= 'C' string int string*
+	fields := getFields(typ)
+	b := encByte(nil, BC_OBJECT_DEF)
+	b = encString(b, javaName)
+	b = encInt32(b, int32(len(fields)))
+	l := make([]string, 0, len(fields))
+	for _, f := range fields {
+		l = append(l, f.name)
+		b = encString(b, f.name)
+	}
+
+	return classInfo{javaName: javaName, fieldNameList: l, buffer: b}
+}
+
+// getStructJavaName returns the java class name of go struct type @typ which does not
+// implement POJO. The name is got from the registry, or from the hessian tag of the
+// blank field of @typ, eg: _ struct{} `hessian:"com.test.User"`.
+// The return value is "" if @typ has no java class name.
+func getStructJavaName(typ reflect.Type) string {
+	pojoRegistry.RLock()
+	s, ok := pojoRegistry.registry[typ.String()]
+	pojoRegistry.RUnlock()
+	if ok {
+		return s.javaName
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Name != "_" {
+			continue
+		}
+		if name := f.Tag.Get(tagIdentifier); name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return ""
+}
+
+// getFieldName returns the java field name of @f, which is named by @strategy if it has no hessian tag.
+// The return value is "" if the field should be ignored.
+func getFieldName(f reflect.StructField, strategy FieldNameStrategy) string {
+	tag := f.Tag.Get(tagIdentifier)
+	if tag == "-" {
+		return ""
+	}
+	if tag != "" {
+		return tag
+	}
+
+	return strategy(f.Name)
+}
+
+// getFields returns the encoded fields of struct type @typ in declaration order.
+// Unexported fields are ignored, and the fields of anonymous struct fields are
+// flattened into @typ just like java subclass inherits the fields of its superclass.
+func getFields(typ reflect.Type) []fieldInfo {
+	naming := getFieldNaming()
+	key := fieldsKey{typ: typ, version: naming.version}
+	if fields, ok := fieldsCache.Load(key); ok {
+		return fields.([]fieldInfo)
+	}
+
+	var (
+		fields []fieldInfo
+		all    = appendFields(nil, typ, nil, map[reflect.Type]bool{typ: true}, naming.strategy)
+		depth  = make(map[string]int) // java field name --> the shallowest depth
+	)
+
+	// the field of outer struct hides the same name fields of the embedded structs
+	for _, f := range all {
+		if d, ok := depth[f.name]; !ok || len(f.index) < d {
+			depth[f.name] = len(f.index)
+		}
+	}
+	for _, f := range all {
+		if depth[f.name] == len(f.index) {
+			fields = append(fields, f)
+			depth[f.name] = -1
+		}
+	}
+
+	fieldsCache.Store(key, fields)
+	return fields
+}
+
+// appendFields appends the fields of struct type @typ, whose index sequence is @index,
+// to @fields. @path is the embedded struct types from the outermost to @typ, so that a
+// struct embedding itself, eg: type Node struct{ *Node }, is not flattened forever.
+func appendFields(fields []fieldInfo, typ reflect.Type, index []int, path map[reflect.Type]bool, strategy FieldNameStrategy) []fieldInfo {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		idx := make([]int, len(index)+1)
+		copy(idx, index)
+		idx[len(index)] = i
+
+		if f.Anonymous && f.Tag.Get(tagIdentifier) == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+				// can not allocate an unexported embedded pointer
+				if f.PkgPath != "" {
+					continue
+				}
+			}
+			if path[ft] {
+				continue
+			}
+			if isFlattenedStruct(ft) {
+				path[ft] = true
+				fields = appendFields(fields, ft, idx, path, strategy)
+				delete(path, ft)
+				continue
+			}
+		}
+		if f.PkgPath != "" { // unexported
+			continue
+		}
+
+		n := getFieldName(f, strategy)
+		if n == "" {
+			continue
+		}
+		fields = append(fields, fieldInfo{name: n, index: idx})
+	}
+
+	return fields
+}
+
+// isFlattenedStruct checks whether the fields of embedded type @typ are flattened into the
+// outer struct. The structs which are encoded as one value, eg: time.Time or a POJO, are not.
+func isFlattenedStruct(typ reflect.Type) bool {
+	if typ.Kind() != reflect.Struct || valueStructTypes[typ] {
+		return false
+	}
+
+	return !typ.Implements(pojoType) && !reflect.PtrTo(typ).Implements(pojoType)
+}
+
+// fieldByIndex returns the nested field of struct value @v by @index.
+// The nil embedded struct pointers on the way are allocated if @alloc is true,
+// otherwise an invalid value is returned when a nil pointer is met.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+
+	return v
+}
+
 // Register a value type JavaEnum variable.
 func RegisterJavaEnum(o POJOEnum) int {
 	var (
@@ -253,21 +504,3 @@ func getStructDefByIndex(idx int) (reflect.Type, classInfo, error) {
 
 	return s.typ, cls, nil
 }
-
-// Create a new instance by its struct name is @goName.
-// the return value is nil if @o has been registered.
-func createInstance(goName string) interface{} {
-	var (
-		ok bool
-		s  structInfo
-	)
-
-	pojoRegistry.RLock()
-	s, ok = pojoRegistry.registry[goName]
-	pojoRegistry.RUnlock()
-	if !ok {
-		return nil
-	}
-
-	return reflect.New(s.typ).Interface()
-}
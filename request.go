@@ -48,15 +48,12 @@ func getArgType(v interface{}) string {
 		return "B"
 	case int16:
 		return "S"
-	case uint16: // 相当于Java的Char
-		return "C"
-	// case rune:
-	//	return "C"
-	case int:
+	// uint16 is encoded as hessian int, but java char is read from a string
+	case int32, uint16:
 		return "I"
-	case int32:
-		return "I"
-	case int64:
+	case int, int64: // int 按照 long 编码
+		return "J"
+	case uint32, uint, uint64: // 按照 long 编码
 		return "J"
 	case time.Time:
 		return "java.util.Date"
@@ -82,6 +79,10 @@ func getArgType(v interface{}) string {
 		case reflect.Struct:
 			return "java.lang.Object"
 		case reflect.Slice, reflect.Array:
+			// the slice encoded as a java array, see getListType
+			if listType := getListType(t); listType != "" {
+				return getArrayDesc(listType)
+			}
 			// return "java.util.ArrayList"
 			return "java.util.List"
 		case reflect.Map: // 进入这个case，就说明map可能是map[string]int这种类型
@@ -94,6 +95,26 @@ func getArgType(v interface{}) string {
 	return "java.lang.RuntimeException"
 }
 
+// the java type descriptors of the java array types of primitive types and string
+var arrayDescs = map[string]string{
+	ARRAY_STRING: "[Ljava/lang/String;",
+	ARRAY_INT:    "[I",
+	ARRAY_LONG:   "[J",
+	ARRAY_DOUBLE: "[D",
+	ARRAY_FLOAT:  "[F",
+	ARRAY_BOOL:   "[Z",
+}
+
+// getArrayDesc returns the java type descriptor of java array type @listType,
+// eg: [int -> [I, [com.test.User -> [Lcom/test/User;
+func getArrayDesc(listType string) string {
+	if desc, ok := arrayDescs[listType]; ok {
+		return desc
+	}
+
+	return "[L" + strings.Replace(listType[1:], ".", "/", -1) + ";"
+}
+
 func getArgsTypeList(args []interface{}) (string, error) {
 	var (
 		typ   string
@@ -150,7 +171,8 @@ func packRequest(service Service, header DubboHeader, params interface{}) ([]byt
 	// request id
 	binary.BigEndian.PutUint64(byteArray[4:], uint64(header.ID))
 
-	encoder := NewEncoder()
+	encoder := getEncoder()
+	defer putEncoder(encoder)
 	encoder.Append(byteArray[:HEADER_LENGTH])
 
 	// com.alibaba.dubbo.rpc.protocol.dubbo.DubboCodec.DubboCodec.java line144 encodeRequestData
@@ -190,11 +212,13 @@ func packRequest(service Service, header DubboHeader, params interface{}) ([]byt
 	encoder.Encode(serviceParams)
 
 END:
-	byteArray = encoder.Buffer()
-	pkgLen = len(byteArray)
+	pkgLen = len(encoder.Buffer())
 	if pkgLen > int(DEFAULT_LEN) { // 8M
 		return nil, jerrors.Errorf("Data length %d too large, max payload %d", pkgLen, DEFAULT_LEN)
 	}
+	// the encoder will be put back to the pool, so its buffer can not be returned
+	byteArray = make([]byte, pkgLen)
+	copy(byteArray, encoder.Buffer())
 	// byteArray{body length}
 	binary.BigEndian.PutUint32(byteArray[12:], uint32(pkgLen-HEADER_LENGTH))
 	return byteArray, nil
@@ -216,7 +240,8 @@ func unpackRequestBody(buf []byte, reqObj interface{}) error {
 		dubboVersion, target, serviceVersion, method, argsTypes interface{}
 		args                                                    []interface{}
 	)
-	decoder := NewDecoder(buf[:])
+	decoder := getDecoder(buf[:])
+	defer putDecoder(decoder)
 
 	dubboVersion, err = decoder.Decode()
 	if err != nil {
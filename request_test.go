@@ -44,6 +44,46 @@ func TestPackRequest(t *testing.T) {
 	}
 }
 
+func TestGetArgsTypeList(t *testing.T) {
+	types, err := getArgsTypeList([]interface{}{int8(1), int16(1), uint16(1), int32(1), int(1), int64(1), uint64(1), "a"})
+	assert.Nil(t, err)
+	assert.Equal(t, "BSIIJJJLjava/lang/String;", types)
+
+	// the slices encoded as java arrays
+	types, err = getArgsTypeList([]interface{}{[]string{"a"}, []int32{1}, []int64{1}, []int{1}, []float64{1}, []float32{1}, []bool{true}})
+	assert.Nil(t, err)
+	assert.Equal(t, "[Ljava/lang/String;[I[J[J[D[F[Z", types)
+
+	types, err = getArgsTypeList([]interface{}{[]*Case{{A: "a"}}, []interface{}{"a"}})
+	assert.Nil(t, err)
+	assert.Equal(t, "[Lcom/test/case;Ljava/util/List;", types)
+}
+
+func TestArgTypeOfEncoding(t *testing.T) {
+	// the descriptor of an argument should match the type it is encoded as
+	args := []interface{}{int8(1), int16(1), int32(1), uint8(1), uint16(1), int(1), int64(1), uint32(1), uint(1), uint64(1)}
+	for _, arg := range args {
+		e := NewEncoder()
+		if err := e.Encode(arg); err != nil {
+			t.Fatalf("Encode(%T) = error:%s", arg, err)
+		}
+		decoded, err := NewDecoder(e.Buffer()).Decode()
+		if err != nil {
+			t.Fatalf("Decode(%T) = error:%s", arg, err)
+		}
+
+		desc := getArgType(arg)
+		switch decoded.(type) {
+		case int32:
+			assert.Contains(t, []string{"B", "S", "I"}, desc, "%T", arg)
+		case int64:
+			assert.Equal(t, "J", desc, "%T", arg)
+		default:
+			t.Errorf("%T is decoded as %T", arg, decoded)
+		}
+	}
+}
+
 func TestDescRegex(t *testing.T) {
 	results := DescRegex.FindAllString("Ljava/lang/String;", -1)
 	assert.Equal(t, 1, len(results))
@@ -71,3 +111,46 @@ func TestDescRegex(t *testing.T) {
 	assert.Equal(t, "[Ljava/lang/String;", results[0])
 	assert.Equal(t, "[I", results[1])
 }
+
+func benchmarkRequest() (Service, DubboHeader, []interface{}) {
+	return Service{
+		Path:      "/test",
+		Interface: "ITest",
+		Version:   "v1.0",
+		Target:    "test",
+		Method:    "test",
+		Timeout:   time.Second * 10,
+	}, DubboHeader{
+		SerialID: 2,
+		Type:     Request,
+		ID:       123,
+	}, []interface{}{"hello", int32(1), map[string]string{"dubbo": "go"}}
+}
+
+func BenchmarkPackRequest(b *testing.B) {
+	service, header, args := benchmarkRequest()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := packRequest(service, header, args); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnpackRequestBody(b *testing.B) {
+	service, header, args := benchmarkRequest()
+	buf, err := packRequest(service, header, args)
+	if err != nil {
+		b.Fatal(err)
+	}
+	body := buf[HEADER_LENGTH:]
+	req := make([]interface{}, 7)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err = unpackRequestBody(body, req); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -53,7 +53,8 @@ func packResponse(header DubboHeader, attachments map[string]string, ret interfa
 	binary.BigEndian.PutUint64(byteArray[4:], uint64(header.ID))
 
 	// body
-	encoder := NewEncoder()
+	encoder := getEncoder()
+	defer putEncoder(encoder)
 	encoder.Append(byteArray[:HEADER_LENGTH])
 
 	if hb {
@@ -76,8 +77,13 @@ func packResponse(header DubboHeader, attachments map[string]string, ret interfa
 		}
 
 		if e, ok := ret.(error); ok { // throw error
+			// java consumers can only catch java exceptions
+			t, ok := e.(*JavaThrowable)
+			if !ok {
+				t = NewJavaException("", e)
+			}
 			encoder.Encode(resWithException)
-			encoder.Encode(e.Error())
+			encoder.Encode(t)
 		} else {
 			if ret == nil {
 				encoder.Encode(resNullValue)
@@ -92,12 +98,14 @@ func packResponse(header DubboHeader, attachments map[string]string, ret interfa
 		}
 	}
 
-	byteArray = encoder.Buffer()
-	byteArray = encNull(byteArray) // if not, "java client" will throw exception  "unexpected end of file"
-	pkgLen := len(byteArray)
+	encoder.buffer = encNull(encoder.buffer) // if not, "java client" will throw exception  "unexpected end of file"
+	pkgLen := len(encoder.Buffer())
 	if pkgLen > int(DEFAULT_LEN) { // 8M
 		return nil, jerrors.Errorf("Data length %d too large, max payload %d", pkgLen, DEFAULT_LEN)
 	}
+	// the encoder will be put back to the pool, so its buffer can not be returned
+	byteArray = make([]byte, pkgLen)
+	copy(byteArray, encoder.Buffer())
 	// byteArray{body length}
 	binary.BigEndian.PutUint32(byteArray[12:], uint32(pkgLen-HEADER_LENGTH))
 	return byteArray, nil
@@ -108,7 +116,8 @@ func packResponse(header DubboHeader, attachments map[string]string, ret interfa
 // todo: need to read attachments, but don't known it's effect yet
 func unpackResponseBody(buf []byte, rspObj interface{}) error {
 	// body
-	decoder := NewDecoder(buf[:])
+	decoder := getDecoder(buf[:])
+	defer putDecoder(decoder)
 	rspType, err := decoder.Decode()
 	if err != nil {
 		return jerrors.Trace(err)
@@ -120,6 +129,10 @@ func unpackResponseBody(buf []byte, rspObj interface{}) error {
 		if err != nil {
 			return jerrors.Trace(err)
 		}
+		// return the java exception as it is, so that it can be inspected by errors.As
+		if t, ok := unpackDecodedValue(expt).(*JavaThrowable); ok {
+			return t
+		}
 		return jerrors.Errorf("got exception: %+v", expt)
 
 	case RESPONSE_VALUE, RESPONSE_VALUE_WITH_ATTACHMENTS:
@@ -137,7 +150,10 @@ func unpackResponseBody(buf []byte, rspObj interface{}) error {
 }
 
 func CopySlice(inSlice, outSlice reflect.Value) error {
-	if inSlice.IsNil() {
+	if inSlice.IsValid() && inSlice.CanInterface() {
+		inSlice = reflect.ValueOf(unpackJavaCollection(inSlice.Interface()))
+	}
+	if !inSlice.IsValid() || inSlice.IsNil() {
 		return jerrors.New("@in is nil")
 	}
 	if inSlice.Kind() != reflect.Slice {
@@ -164,7 +180,10 @@ func CopySlice(inSlice, outSlice reflect.Value) error {
 }
 
 func CopyMap(inMapValue, outMapValue reflect.Value) error {
-	if inMapValue.IsNil() {
+	if inMapValue.IsValid() && inMapValue.CanInterface() {
+		inMapValue = reflect.ValueOf(unpackJavaCollection(inMapValue.Interface()))
+	}
+	if !inMapValue.IsValid() || inMapValue.IsNil() {
 		return jerrors.New("@in is nil")
 	}
 	if !inMapValue.CanInterface() {
@@ -212,7 +231,11 @@ func ReflectResponse(in interface{}, out interface{}) error {
 		return jerrors.Errorf("@out should be a pointer")
 	}
 
-	inValue := EnsurePackValue(in)
+	// the go value of java collection or map, eg: java.util.LinkedList
+	inValue := EnsurePackValue(unpackJavaCollection(in))
+	if !inValue.IsValid() {
+		return jerrors.Errorf("@in is nil")
+	}
 	outValue := EnsurePackValue(out)
 
 	switch inValue.Type().Kind() {
@@ -98,6 +98,46 @@ func TestReflectResponse(t *testing.T) {
 }
 
 // separately test copy normal map to map[interface{}]interface{}
+func TestUnpackJavaCollectionResponse(t *testing.T) {
+	header := DubboHeader{SerialID: 2, Type: Response, ID: 1}
+	cases := []struct {
+		ret      interface{}
+		expected []interface{}
+	}{
+		{JavaList{Class: "java.util.LinkedList", List: []interface{}{"a", "b"}}, []interface{}{"a", "b"}},
+		{JavaSet{Values: []string{"a"}}, []interface{}{"a"}},
+		{JavaList{Class: "[object", List: []interface{}{"a", int32(1)}}, []interface{}{"a", int32(1)}},
+	}
+
+	for _, c := range cases {
+		buf, err := packResponse(header, nil, c.ret)
+		if err != nil {
+			t.Fatalf("packResponse(%#v) = error:%s", c.ret, err)
+		}
+		rsp := []interface{}{}
+		if err = unpackResponseBody(buf[HEADER_LENGTH:], &rsp); err != nil {
+			t.Fatalf("unpackResponseBody(%#v) = error:%s", c.ret, err)
+		}
+		assert.Equal(t, c.expected, rsp)
+	}
+}
+
+func TestUnpackJavaMapResponse(t *testing.T) {
+	header := DubboHeader{SerialID: 2, Type: Response, ID: 1}
+	for _, class := range []string{"java.util.LinkedHashMap", "java.util.TreeMap", "java.util.Hashtable"} {
+		ret := JavaMap{Class: class, Map: map[string]int32{"a": 1, "b": 2}}
+		buf, err := packResponse(header, nil, ret)
+		if err != nil {
+			t.Fatalf("packResponse(%#v) = error:%s", ret, err)
+		}
+		rsp := map[interface{}]interface{}{}
+		if err = unpackResponseBody(buf[HEADER_LENGTH:], &rsp); err != nil {
+			t.Fatalf("unpackResponseBody(%#v) = error:%s", ret, err)
+		}
+		assert.Equal(t, map[interface{}]interface{}{"a": int32(1), "b": int32(2)}, rsp)
+	}
+}
+
 func TestCopyMap(t *testing.T) {
 	type rr struct {
 		Name string
@@ -204,3 +244,32 @@ func TestVersion2Int(t *testing.T) {
 	assert.Equal(t, 201030405, v)
 
 }
+
+func BenchmarkPackResponse(b *testing.B) {
+	header := DubboHeader{SerialID: 2, Type: Response, ID: 123}
+	ret := []interface{}{"hello", int32(1), map[string]string{"dubbo": "go"}}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := packResponse(header, nil, ret); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkUnpackResponseBody(b *testing.B) {
+	header := DubboHeader{SerialID: 2, Type: Response, ID: 123}
+	buf, err := packResponse(header, nil, "hello world")
+	if err != nil {
+		b.Fatal(err)
+	}
+	body := buf[HEADER_LENGTH:]
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var rsp string
+		if err = unpackResponseBody(body, &rsp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -47,47 +47,40 @@ func Slice(s string) (b []byte) {
 // ::= [x00-x1f] <utf8-data>         # string of length 0-31
 // ::= [x30-x34] <utf8-data>         # string of length 0-1023
 func encString(b []byte, v string) []byte {
-	var (
-		vBuf = *bytes.NewBufferString(v)
-		vLen = utf8.RuneCountInString(v)
-
-		vChunk = func(length int) {
-			for i := 0; i < length; i++ {
-				if r, s, err := vBuf.ReadRune(); s > 0 && err == nil {
-					// b = append(b, []byte(string(r))...)
-					b = append(b, Slice(string(r))...) // 直接基于r的内存空间把它转换为[]byte
-				}
-			}
+	for {
+		b, v = encStringChunk(b, v)
+		if v == "" {
+			return b
 		}
+	}
+}
+
+// encStringChunk encodes the first chunk of @v, and returns the left string.
+func encStringChunk(b []byte, v string) ([]byte, string) {
+	var (
+		vLen  int // rune count of chunk
+		bytes int // byte count of chunk
 	)
 
-	if v == "" {
-		return encByte(b, BC_STRING_DIRECT)
+	for vLen < CHUNK_SIZE && bytes < len(v) {
+		_, size := utf8.DecodeRuneInString(v[bytes:])
+		bytes += size
+		vLen++
 	}
 
-	for {
-		vLen = utf8.RuneCount(vBuf.Bytes())
-		if vLen == 0 {
-			break
-		}
-		if vLen > CHUNK_SIZE {
-			b = encByte(b, BC_STRING_CHUNK)
-			b = encByte(b, PackUint16(uint16(CHUNK_SIZE))...)
-			vChunk(CHUNK_SIZE)
-		} else {
-			if vLen <= int(STRING_DIRECT_MAX) {
-				b = encByte(b, byte(vLen+int(BC_STRING_DIRECT)))
-			} else if vLen <= int(STRING_SHORT_MAX) {
-				b = encByte(b, byte((vLen>>8)+int(BC_STRING_SHORT)), byte(vLen))
-			} else {
-				b = encByte(b, BC_STRING)
-				b = encByte(b, PackUint16(uint16(vLen))...)
-			}
-			vChunk(vLen)
-		}
+	if bytes < len(v) {
+		b = encByte(b, BC_STRING_CHUNK)
+		b = encByte(b, PackUint16(uint16(vLen))...)
+	} else if vLen <= int(STRING_DIRECT_MAX) {
+		b = encByte(b, byte(vLen+int(BC_STRING_DIRECT)))
+	} else if vLen <= int(STRING_SHORT_MAX) {
+		b = encByte(b, byte((vLen>>8)+int(BC_STRING_SHORT)), byte(vLen))
+	} else {
+		b = encByte(b, BC_STRING)
+		b = encByte(b, PackUint16(uint16(vLen))...)
 	}
 
-	return b
+	return append(b, v[:bytes]...), v[bytes:]
 }
 
 /////////////////////////////////////////
@@ -128,7 +121,7 @@ func (d *Decoder) getStringLength(tag byte) (int32, error) {
 		return length, nil
 
 	default:
-		return -1, jerrors.Trace(err)
+		return -1, jerrors.Errorf("illegal string tag %#x", tag)
 	}
 }
 
@@ -161,6 +154,7 @@ func getRune(reader io.Reader) (rune, int, error) {
 // hessian-lite/src/main/java/com/alibaba/com/caucho/hessian/io/Hessian2Input.java : readString
 func (d *Decoder) decString(flag int32) (string, error) {
 	var (
+		err    error
 		tag    byte
 		length int32
 		last   bool
@@ -171,7 +165,10 @@ func (d *Decoder) decString(flag int32) (string, error) {
 	if flag != TAG_READ {
 		tag = byte(flag)
 	} else {
-		tag, _ = d.readByte()
+		tag, err = d.readByte()
+		if err != nil {
+			return "", jerrors.Trace(err)
+		}
 	}
 
 	switch {
@@ -207,7 +204,7 @@ func (d *Decoder) decString(flag int32) (string, error) {
 			return "", jerrors.Annotatef(err, "tag:%+v", tag)
 		}
 
-		return strconv.FormatFloat(f.(float64), 'E', -1, 64), nil
+		return strconv.FormatFloat(f, 'E', -1, 64), nil
 	}
 
 	last = true
@@ -233,11 +230,14 @@ func (d *Decoder) decString(flag int32) (string, error) {
 					return string(runeDate), nil
 				}
 
-				b, _ := d.readByte()
+				b, err := d.readByte()
+				if err != nil {
+					return s, jerrors.Trace(err)
+				}
 				switch {
-				case (tag >= BC_STRING_DIRECT && tag <= STRING_DIRECT_MAX) ||
-					(tag >= 0x30 && tag <= 0x33) ||
-					(tag == BC_STRING_CHUNK || tag == BC_STRING):
+				case (b >= BC_STRING_DIRECT && b <= STRING_DIRECT_MAX) ||
+					(b >= 0x30 && b <= 0x33) ||
+					(b == BC_STRING_CHUNK || b == BC_STRING):
 
 					if b == BC_STRING_CHUNK {
 						last = false
@@ -255,7 +255,7 @@ func (d *Decoder) decString(flag int32) (string, error) {
 					runeDate = bs
 
 				default:
-					return s, jerrors.Trace(err)
+					return s, jerrors.Errorf("illegal string chunk tag %#x", b)
 				}
 
 			} else {
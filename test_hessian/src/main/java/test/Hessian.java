@@ -1,5 +1,6 @@
 package test;
 
+import com.caucho.hessian.io.Hessian2Input;
 import com.caucho.hessian.io.Hessian2Output;
 import com.caucho.hessian.test.TestHessian2Servlet;
 
@@ -8,13 +9,54 @@ import java.lang.reflect.Method;
 
 public class Hessian {
     public static void main(String[] args) throws Exception {
-        Method method = TestHessian2Servlet.class.getMethod(args[0]);
+        if (args[0].startsWith("java8")) {
+            java8(args[0]);
+            return;
+        }
 
-        TestHessian2Servlet servlet = new TestHessian2Servlet();
-        Object object = method.invoke(servlet);
+        if (args[0].startsWith("arg") || args[0].startsWith("customArg")) {
+            // decode the object written by go from stdin, and check it
+            Class<?> clazz = args[0].startsWith("arg") ? TestHessian2Servlet.class : TestCustomDecode.class;
+            Method method = clazz.getMethod(args[0], Object.class);
+
+            Hessian2Input input = new Hessian2Input(System.in);
+            Object object = method.invoke(clazz.newInstance(), input.readObject());
+            System.out.print(object);
+            return;
+        }
+
+        Object object;
+        if (args[0].startsWith("customReply")) {
+            Method method = TestCustomReply.class.getMethod(args[0]);
+            object = method.invoke(new TestCustomReply());
+        } else {
+            Method method = TestHessian2Servlet.class.getMethod(args[0]);
+            object = method.invoke(new TestHessian2Servlet());
+        }
 
         Hessian2Output output = new Hessian2Output(System.out);
         output.writeObject(object);
         output.flush();
     }
-}
\ No newline at end of file
+
+    // java.time is only supported by hessian-lite
+    private static void java8(String methodName) throws Exception {
+        if (methodName.startsWith("java8Arg")) {
+            Method method = TestJava8.class.getMethod(methodName, Object.class);
+
+            com.alibaba.com.caucho.hessian.io.Hessian2Input input =
+                    new com.alibaba.com.caucho.hessian.io.Hessian2Input(System.in);
+            Object object = method.invoke(new TestJava8(), input.readObject());
+            System.out.print(object);
+            return;
+        }
+
+        Method method = TestJava8.class.getMethod(methodName);
+        Object object = method.invoke(new TestJava8());
+
+        com.alibaba.com.caucho.hessian.io.Hessian2Output output =
+                new com.alibaba.com.caucho.hessian.io.Hessian2Output(System.out);
+        output.writeObject(object);
+        output.flush();
+    }
+}
@@ -0,0 +1,86 @@
+package test;
+
+import java.math.BigDecimal;
+import java.math.BigInteger;
+import java.util.Arrays;
+import java.util.HashMap;
+import java.util.HashSet;
+import java.util.LinkedHashMap;
+import java.util.LinkedList;
+import java.util.Map;
+import java.util.TreeMap;
+import java.util.TreeSet;
+import java.util.concurrent.ConcurrentHashMap;
+
+
+/**
+ * Check the objects encoded by go, which are not covered by TestHessian2Servlet.
+ */
+public class TestCustomDecode {
+    public Object customArgBigDecimal(Object o) {
+        return new BigDecimal("100.256").equals(o);
+    }
+
+    public Object customArgBigDecimalExp(Object o) {
+        return new BigDecimal("-1.23E-10").equals(o);
+    }
+
+    public Object customArgBigInteger(Object o) {
+        return new BigInteger("-123456789012345678901234567890123456789").equals(o);
+    }
+
+    public Object customArgBigIntegerZero(Object o) {
+        return BigInteger.ZERO.equals(o);
+    }
+
+    private static Map<String, Integer> typedMap() {
+        Map<String, Integer> map = new HashMap<>();
+        map.put("a", 1);
+        map.put("b", 2);
+        return map;
+    }
+
+    public Object customArgTreeMap(Object o) {
+        return o instanceof TreeMap && typedMap().equals(o);
+    }
+
+    public Object customArgLinkedHashMap(Object o) {
+        return o instanceof LinkedHashMap && typedMap().equals(o);
+    }
+
+    public Object customArgConcurrentHashMap(Object o) {
+        return o instanceof ConcurrentHashMap && typedMap().equals(o);
+    }
+
+    public Object customArgEmptyTreeMap(Object o) {
+        return o instanceof TreeMap && ((TreeMap) o).isEmpty();
+    }
+
+    public Object customArgHashSet(Object o) {
+        return o instanceof HashSet && new HashSet<>(Arrays.asList("a", "b")).equals(o);
+    }
+
+    public Object customArgTreeSet(Object o) {
+        return o instanceof TreeSet && new TreeSet<>(Arrays.asList(1, 2)).equals(o);
+    }
+
+    public Object customArgLinkedList(Object o) {
+        return o instanceof LinkedList && Arrays.asList("a", "b").equals(o);
+    }
+
+    public Object customArgObjectArray(Object o) {
+        return o instanceof Object[] && Arrays.equals(new Object[]{"a", 1}, (Object[]) o);
+    }
+
+    public Object customArgRuntimeException(Object o) {
+        if (!(o instanceof RuntimeException)) {
+            return false;
+        }
+        RuntimeException e = (RuntimeException) o;
+        Throwable cause = e.getCause();
+        return "wrap: java.lang.IllegalStateException: illegal state".equals(e.getMessage())
+                && cause instanceof IllegalStateException && "illegal state".equals(cause.getMessage())
+                && cause.getCause() == null && e.getStackTrace().length > 0
+                && e.getSuppressed().length == 0 && e.toString() != null;
+    }
+}
@@ -0,0 +1,53 @@
+package test;
+
+import java.io.IOException;
+import java.math.BigDecimal;
+import java.math.BigInteger;
+import java.util.Arrays;
+import java.util.HashSet;
+import java.util.LinkedList;
+import java.util.TreeSet;
+
+
+/**
+ * The replies of the types which are not covered by TestHessian2Servlet.
+ */
+public class TestCustomReply {
+    public Object customReplyBigDecimal() {
+        return new BigDecimal("100.256");
+    }
+
+    public Object customReplyBigDecimalExp() {
+        return new BigDecimal("-1.23E-10");
+    }
+
+    public Object customReplyBigInteger() {
+        return new BigInteger("-123456789012345678901234567890123456789");
+    }
+
+    public Object customReplyHashSet() {
+        return new HashSet<>(Arrays.asList("a"));
+    }
+
+    public Object customReplyTreeSet() {
+        return new TreeSet<>(Arrays.asList("a", "b"));
+    }
+
+    public Object customReplyLinkedList() {
+        return new LinkedList<>(Arrays.asList("a", "b"));
+    }
+
+    public Object customReplyObjectArray() {
+        return new Object[]{"a", 1};
+    }
+
+    public Object customReplyThrowable() {
+        return new Throwable("throwable");
+    }
+
+    public Object customReplyRuntimeException() {
+        RuntimeException e = new RuntimeException("runtime", new IllegalStateException("illegal state"));
+        e.addSuppressed(new IOException("io"));
+        return e;
+    }
+}
@@ -0,0 +1,70 @@
+package test;
+
+import java.time.Duration;
+import java.time.Instant;
+import java.time.LocalDate;
+import java.time.LocalDateTime;
+import java.time.LocalTime;
+import java.time.ZoneId;
+import java.time.ZonedDateTime;
+
+
+/**
+ * The java.time replies and checks, which are written and read by hessian-lite.
+ */
+public class TestJava8 {
+    private static final LocalDate DATE = LocalDate.of(2019, 5, 20);
+    private static final LocalTime TIME = LocalTime.of(13, 14, 15, 123456789);
+    private static final LocalDateTime DATE_TIME = LocalDateTime.of(DATE, TIME);
+    private static final Instant INSTANT = Instant.ofEpochSecond(1558329255, 123456789);
+    private static final Duration DURATION = Duration.ofSeconds(-90, 5);
+    private static final ZonedDateTime ZONED_DATE_TIME = ZonedDateTime.of(DATE_TIME, ZoneId.of("Asia/Shanghai"));
+
+    public Object java8ReplyLocalDate() {
+        return DATE;
+    }
+
+    public Object java8ReplyLocalTime() {
+        return TIME;
+    }
+
+    public Object java8ReplyLocalDateTime() {
+        return DATE_TIME;
+    }
+
+    public Object java8ReplyInstant() {
+        return INSTANT;
+    }
+
+    public Object java8ReplyDuration() {
+        return DURATION;
+    }
+
+    public Object java8ReplyZonedDateTime() {
+        return ZONED_DATE_TIME;
+    }
+
+    public Object java8ArgLocalDate(Object o) {
+        return DATE.equals(o);
+    }
+
+    public Object java8ArgLocalTime(Object o) {
+        return TIME.equals(o);
+    }
+
+    public Object java8ArgLocalDateTime(Object o) {
+        return DATE_TIME.equals(o);
+    }
+
+    public Object java8ArgInstant(Object o) {
+        return INSTANT.equals(o);
+    }
+
+    public Object java8ArgDuration(Object o) {
+        return DURATION.equals(o);
+    }
+
+    public Object java8ArgZonedDateTime(Object o) {
+        return ZONED_DATE_TIME.equals(o);
+    }
+}